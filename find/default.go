@@ -0,0 +1,179 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package find
+
+import (
+	"context"
+
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// DefaultResolver selects the object returned by each of the Finder Default* methods.
+// NotFoundError and MultipleFoundError errors returned by a resolver are converted
+// to their Default* counterparts by the Finder.
+// Implementations can embed PatternResolver and override only the methods they need,
+// for example to choose the least loaded datastore.
+type DefaultResolver interface {
+	DefaultDatacenter(ctx context.Context, f *Finder) (*object.Datacenter, error)
+	DefaultDatastore(ctx context.Context, f *Finder) (*object.Datastore, error)
+	DefaultDatastoreCluster(ctx context.Context, f *Finder) (*object.StoragePod, error)
+	DefaultComputeResource(ctx context.Context, f *Finder) (*object.ComputeResource, error)
+	DefaultHostSystem(ctx context.Context, f *Finder) (*object.HostSystem, error)
+	DefaultNetwork(ctx context.Context, f *Finder) (object.NetworkReference, error)
+	DefaultResourcePool(ctx context.Context, f *Finder) (*object.ResourcePool, error)
+	DefaultFolder(ctx context.Context, f *Finder) (*object.Folder, error)
+}
+
+// PatternResolver is the built-in DefaultResolver, which uses a wildcard pattern for each type
+// and succeeds only when the pattern resolves to a single object.
+type PatternResolver struct{}
+
+func (PatternResolver) DefaultDatacenter(ctx context.Context, f *Finder) (*object.Datacenter, error) {
+	return f.Datacenter(ctx, "*")
+}
+
+func (PatternResolver) DefaultDatastore(ctx context.Context, f *Finder) (*object.Datastore, error) {
+	return f.Datastore(ctx, "*")
+}
+
+func (PatternResolver) DefaultDatastoreCluster(ctx context.Context, f *Finder) (*object.StoragePod, error) {
+	return f.DatastoreCluster(ctx, "*")
+}
+
+func (PatternResolver) DefaultComputeResource(ctx context.Context, f *Finder) (*object.ComputeResource, error) {
+	return f.ComputeResource(ctx, "*")
+}
+
+func (PatternResolver) DefaultHostSystem(ctx context.Context, f *Finder) (*object.HostSystem, error) {
+	return f.HostSystem(ctx, "*/*")
+}
+
+// DefaultNetwork returns the only network, if there is exactly one.  Otherwise, if the default compute resource
+// is connected to exactly one of the networks, ignoring DVS uplink portgroups, that network is returned.
+// If neither applies, the first network is returned when the Finder's firstMatch is set, otherwise the error
+// lists the candidate networks.
+func (PatternResolver) DefaultNetwork(ctx context.Context, f *Finder) (object.NetworkReference, error) {
+	ns, err := f.NetworkList(ctx, "*")
+	if err != nil {
+		return nil, err
+	}
+
+	// DistributedVirtualSwitch objects cannot be attached to
+	var candidates []object.NetworkReference
+	for _, n := range ns {
+		if _, ok := n.(*object.DistributedVirtualSwitch); !ok {
+			candidates = append(candidates, n)
+		}
+	}
+
+	if len(candidates) == 1 || (len(candidates) > 1 && f.firstMatch) {
+		return candidates[0], nil
+	}
+
+	if len(candidates) == 0 {
+		return nil, &NotFoundError{"network", "*"}
+	}
+
+	if n, ok := connectedNetwork(ctx, f, candidates); ok {
+		return n, nil
+	}
+
+	paths := make([]string, len(candidates))
+	for i, n := range candidates {
+		switch n := n.(type) {
+		case *object.Network:
+			paths[i] = n.InventoryPath
+		case *object.DistributedVirtualPortgroup:
+			paths[i] = n.InventoryPath
+		default:
+			paths[i] = n.Reference().String()
+		}
+	}
+
+	return nil, &DefaultMultipleFoundError{kind: "network", candidates: paths}
+}
+
+// connectedNetwork returns the only network in ns connected to the default compute resource, if any.
+func connectedNetwork(ctx context.Context, f *Finder, ns []object.NetworkReference) (object.NetworkReference, bool) {
+	cr, err := f.DefaultComputeResource(ctx)
+	if err != nil {
+		return nil, false
+	}
+
+	var mcr mo.ComputeResource
+
+	err = f.retrieveOne(ctx, cr.Reference(), []string{"network"}, &mcr)
+	if err != nil {
+		return nil, false
+	}
+
+	connected := make(map[types.ManagedObjectReference]bool)
+	var pgs []types.ManagedObjectReference
+
+	for _, ref := range mcr.Network {
+		connected[ref] = true
+		if ref.Type == "DistributedVirtualPortgroup" {
+			pgs = append(pgs, ref)
+		}
+	}
+
+	if len(pgs) != 0 {
+		var mpgs []mo.DistributedVirtualPortgroup
+
+		err = f.retrieve(ctx, pgs, []string{"config.uplink"}, &mpgs)
+		if err != nil {
+			return nil, false
+		}
+
+		for _, pg := range mpgs {
+			if pg.Config.Uplink != nil && *pg.Config.Uplink {
+				delete(connected, pg.Reference())
+			}
+		}
+	}
+
+	var match object.NetworkReference
+
+	for _, n := range ns {
+		if connected[n.Reference()] {
+			if match != nil {
+				return nil, false
+			}
+			match = n
+		}
+	}
+
+	return match, match != nil
+}
+
+func (PatternResolver) DefaultResourcePool(ctx context.Context, f *Finder) (*object.ResourcePool, error) {
+	return f.ResourcePool(ctx, "*/Resources")
+}
+
+// DefaultFolder returns the datacenter's vm folder.
+func (PatternResolver) DefaultFolder(ctx context.Context, f *Finder) (*object.Folder, error) {
+	folders, err := f.dcFolders(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	folder := *folders.VmFolder
+
+	return &folder, nil
+}
@@ -16,7 +16,10 @@ limitations under the License.
 
 package find
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 type NotFoundError struct {
 	kind string
@@ -45,10 +48,14 @@ func (e *DefaultNotFoundError) Error() string {
 }
 
 type DefaultMultipleFoundError struct {
-	kind string
+	kind       string
+	candidates []string
 }
 
 func (e DefaultMultipleFoundError) Error() string {
+	if len(e.candidates) != 0 {
+		return fmt.Sprintf("default %s resolves to multiple instances (%s), please specify", e.kind, strings.Join(e.candidates, ", "))
+	}
 	return fmt.Sprintf("default %s resolves to multiple instances, please specify", e.kind)
 }
 
@@ -57,7 +64,7 @@ func toDefaultError(err error) error {
 	case *NotFoundError:
 		return &DefaultNotFoundError{e.kind}
 	case *MultipleFoundError:
-		return &DefaultMultipleFoundError{e.kind}
+		return &DefaultMultipleFoundError{kind: e.kind}
 	default:
 		return err
 	}
@@ -19,13 +19,21 @@ package find
 import (
 	"context"
 	"errors"
+	"fmt"
+	"net"
 	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/vmware/govmomi/list"
 	"github.com/vmware/govmomi/object"
 	"github.com/vmware/govmomi/property"
 	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/methods"
 	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/soap"
 	"github.com/vmware/govmomi/vim25/types"
 )
 
@@ -35,6 +43,32 @@ type Finder struct {
 
 	dc      *object.Datacenter
 	folders *object.DatacenterFolders
+
+	firstMatch bool
+	sorted     bool
+
+	resolver DefaultResolver
+
+	preferredDatacenter string
+
+	retryMax  int
+	retryBase time.Duration
+
+	collapseNetworks bool
+
+	excludeSystemVMs bool
+
+	excludeUplinks bool
+
+	datacenterPrivileges []string
+
+	exclude []string
+
+	instanceUuids *uuidCache
+
+	root       object.Reference
+	rootPrefix string
+	rootPath   string
 }
 
 func NewFinder(client *vim25.Client, all bool) *Finder {
@@ -44,25 +78,246 @@ func NewFinder(client *vim25.Client, all bool) *Finder {
 			Collector: property.DefaultCollector(client),
 			All:       all,
 		},
+		resolver:      PatternResolver{},
+		instanceUuids: new(uuidCache),
 	}
 
 	return f
 }
 
+// source returns the list.Source the Finder retrieves properties from, which is its
+// property collector unless the Finder was created by FromInventoryFile.
+func (f *Finder) source() list.Source {
+	if f.recurser.Source != nil {
+		return f.recurser.Source
+	}
+
+	return f.recurser.Collector
+}
+
+// retrieveProperties calls RetrieveProperties on the Finder's source, retrying as configured via SetRetry.
+func (f *Finder) retrieveProperties(ctx context.Context, req types.RetrieveProperties) (*types.RetrievePropertiesResponse, error) {
+	var res *types.RetrievePropertiesResponse
+
+	err := f.retry(ctx, func() error {
+		var rerr error
+		res, rerr = f.source().RetrieveProperties(ctx, req)
+		return rerr
+	})
+
+	return res, err
+}
+
+// retrieve is like property.Collector.Retrieve, but retrieves the properties from the Finder's source,
+// retrying as configured via SetRetry.
+func (f *Finder) retrieve(ctx context.Context, objs []types.ManagedObjectReference, ps []string, dst interface{}) error {
+	var spec types.PropertyFilterSpec
+
+	for _, obj := range objs {
+		if len(spec.PropSet) == 0 {
+			spec.PropSet = []types.PropertySpec{{Type: obj.Type, PathSet: ps}}
+			if ps == nil {
+				spec.PropSet[0].All = types.NewBool(true)
+			}
+		} else if obj.Type != spec.PropSet[0].Type {
+			return errors.New("object references must have the same type")
+		}
+
+		spec.ObjectSet = append(spec.ObjectSet, types.ObjectSpec{Obj: obj, Skip: types.NewBool(false)})
+	}
+
+	req := types.RetrieveProperties{
+		SpecSet: []types.PropertyFilterSpec{spec},
+	}
+
+	res, err := f.retrieveProperties(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	return mo.LoadRetrievePropertiesResponse(res, dst)
+}
+
+// retrieveOne calls retrieve with a single managed object reference.
+func (f *Finder) retrieveOne(ctx context.Context, obj types.ManagedObjectReference, ps []string, dst interface{}) error {
+	return f.retrieve(ctx, []types.ManagedObjectReference{obj}, ps, dst)
+}
+
+// Client returns the client used by the Finder.
+func (f *Finder) Client() *vim25.Client {
+	return f.client
+}
+
+// WithClient returns a copy of the Finder bound to the given client, such as after a session re-login.
+// The datacenter and other settings are preserved; datacenter folders are looked up again on demand.
+func (f *Finder) WithClient(c *vim25.Client) *Finder {
+	n := *f
+	n.client = c
+	n.recurser.Collector = property.DefaultCollector(c)
+	n.folders = nil
+	n.instanceUuids = new(uuidCache)
+
+	if f.dc != nil {
+		n.dc = object.NewDatacenter(c, f.dc.Reference())
+		n.dc.InventoryPath = f.dc.InventoryPath
+	}
+
+	return &n
+}
+
 func (f *Finder) SetDatacenter(dc *object.Datacenter) *Finder {
 	f.dc = dc
 	f.folders = nil
+	f.instanceUuids.reset()
+	return f
+}
+
+// Reset clears the lookups cached by the Finder, such as those of VirtualMachineByInstanceUuid.
+func (f *Finder) Reset() *Finder {
+	f.folders = nil
+	f.instanceUuids.reset()
+	return f
+}
+
+// UseDatacenter resolves the datacenter with the given name or path and sets it as the Finder's datacenter.
+// The Finder is returned for chaining; on error the Finder's datacenter is unchanged.
+func (f *Finder) UseDatacenter(ctx context.Context, name string) (*Finder, error) {
+	dc, err := f.Datacenter(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return f.SetDatacenter(dc), nil
+}
+
+// SetDefaultResolver configures the resolver used by the Default* methods.
+// Passing nil restores the built-in PatternResolver.
+func (f *Finder) SetDefaultResolver(r DefaultResolver) *Finder {
+	if r == nil {
+		r = PatternResolver{}
+	}
+	f.resolver = r
+	return f
+}
+
+// SetPreferredDatacenter configures DatacenterList to return the datacenter with the given name
+// or inventory path first, if present in the results.  Results are otherwise sorted by name, then by inventory path.
+func (f *Finder) SetPreferredDatacenter(name string) *Finder {
+	f.preferredDatacenter = name
+	return f
+}
+
+// SetCollapseNetworks configures NetworkList to omit a Network when a DistributedVirtualPortgroup
+// with the same backing is also found, treating the two as the same network.  See collapseNetworks
+// for how backings are compared.
+func (f *Finder) SetCollapseNetworks(collapse bool) *Finder {
+	f.collapseNetworks = collapse
+	return f
+}
+
+// SetExcludeSystemVMs configures the virtual machine lookup methods to omit cluster system VMs,
+// such as those deployed by vSphere Cluster Services (vCLS).  See IsSystemVM.
+func (f *Finder) SetExcludeSystemVMs(exclude bool) *Finder {
+	f.excludeSystemVMs = exclude
+	return f
+}
+
+// SetExcludeUplinks configures NetworkList to omit DVS uplink portgroups, which cannot be used as VM networks.
+func (f *Finder) SetExcludeUplinks(exclude bool) *Finder {
+	f.excludeUplinks = exclude
+	return f
+}
+
+// SetDatacenterPrivileges configures DatacenterList to include only datacenters on which the user of the
+// current session has all of the given privileges, such as "VirtualMachine.Inventory.Create".
+// Calling it without privileges removes the filter.
+func (f *Finder) SetDatacenterPrivileges(privIDs ...string) *Finder {
+	f.datacenterPrivileges = privIDs
+	return f
+}
+
+// SetExclude configures the Finder to omit objects matching any of the given patterns, along with
+// everything below them, such as "vm/templates" or "vm/templates/*".  Relative patterns are relative to
+// the Finder's datacenter, or to the root folder if no datacenter is set.  Matching subtrees are pruned
+// during traversal rather than filtered from the results, such that excluded folders are never listed.
+// Calling it without patterns removes the filter.
+func (f *Finder) SetExclude(patterns []string) *Finder {
+	f.exclude = patterns
+	return f
+}
+
+// excludePaths returns the exclude patterns as absolute inventory paths, as used by list.Recurser.
+func (f *Finder) excludePaths(root string) []string {
+	if len(f.exclude) == 0 {
+		return nil
+	}
+
+	if f.dc != nil && f.dc.InventoryPath != "" {
+		root = f.dc.InventoryPath
+	}
+
+	paths := make([]string, 0, len(f.exclude))
+	for _, p := range f.exclude {
+		if !strings.HasPrefix(p, "/") {
+			p = path.Join(root, p)
+		}
+		paths = append(paths, path.Clean(p))
+	}
+
+	return paths
+}
+
+// SetFirstMatch configures the singular lookup methods, such as Datastore and VirtualMachine,
+// to return the first match sorted by inventory path rather than a MultipleFoundError
+// when the given path resolves to multiple objects.
+func (f *Finder) SetFirstMatch(first bool) *Finder {
+	f.firstMatch = first
+	return f
+}
+
+// SetSorted configures the list methods to return results sorted by inventory path.
+func (f *Finder) SetSorted(sorted bool) *Finder {
+	f.sorted = sorted
+	return f
+}
+
+// SetIncludeHidden configures whether folders tagged as hidden system folders, and their contents,
+// are included when traversing the inventory.  Hidden folders are included by default.
+// See list.Recurser.SkipHidden for how this interacts with the all flag given to NewFinder.
+func (f *Finder) SetIncludeHidden(include bool) *Finder {
+	f.recurser.SkipHidden = !include
 	return f
 }
 
 type findRelativeFunc func(ctx context.Context) (object.Reference, error)
 
 func (f *Finder) find(ctx context.Context, fn findRelativeFunc, tl bool, arg string) ([]list.Element, error) {
+	return f.findWithProperties(ctx, fn, tl, nil, arg)
+}
+
+// findWithProperties is like find, but also retrieves the given properties for each type of
+// managed object in the same property collector request used for traversal.
+func (f *Finder) findWithProperties(ctx context.Context, fn findRelativeFunc, tl bool, props map[string][]string, arg string) ([]list.Element, error) {
 	root := list.Element{
 		Path:   "/",
 		Object: object.NewRootFolder(f.client),
 	}
 
+	if f.root != nil {
+		// Absolute paths are given in display form, relative to the configured root.
+		if strings.HasPrefix(arg, "/") {
+			if !isWithin(path.Clean(arg), f.rootPrefix) {
+				return nil, fmt.Errorf("%s is outside of the inventory root", arg)
+			}
+			arg = path.Join("/", strings.TrimPrefix(path.Clean(arg), f.rootPrefix))
+		}
+
+		root.Path = f.rootPrefix
+		root.Object = f.root
+	}
+
+	base := root.Path
+
 	parts := list.ToParts(arg)
 
 	if len(parts) > 0 {
@@ -72,775 +327,3833 @@ func (f *Finder) find(ctx context.Context, fn findRelativeFunc, tl bool, arg str
 		case ".": // Relative to whatever
 			pivot, err := fn(ctx)
 			if err != nil {
-				return nil, err
+				if f.dc == nil && isDatacenterPath(parts[1:]) {
+					// No datacenter is set and the path is of the form "DC*/vm/web*",
+					// match the datacenter segment from the root folder instead.
+					parts = parts[1:]
+					break
+				}
+
+				if f.dc == nil && len(parts) > 2 {
+					// No datacenter is set and the path is of the form "DC/web*",
+					// match relative to the folder of the datacenter named by the leading segment.
+					if dcs, derr := f.DatacenterList(ctx, parts[1]); derr == nil && len(dcs) == 1 {
+						pivot, err = fn(context.WithValue(ctx, datacenterKey{}, dcs[0]))
+						parts = parts[1:]
+					}
+				}
+
+				if err != nil {
+					return nil, err
+				}
 			}
 
-			mes, err := mo.Ancestors(ctx, f.client, f.client.ServiceContent.PropertyCollector, pivot.Reference())
+			p, err := f.inventoryPath(ctx, pivot.Reference())
 			if err != nil {
 				return nil, err
 			}
 
-			for _, me := range mes {
-				// Skip root entity in building inventory path.
-				if me.Parent == nil {
-					continue
+			parts = parts[1:]
+
+			if f.root != nil {
+				rootPath, err := f.scopedRootPath(ctx)
+				if err != nil {
+					return nil, err
+				}
+
+				if len(parts) > 0 && isWithin(rootPath, p) {
+					// The pivot contains the configured root, such as the vm folder
+					// containing a tenant's folder: match from the root instead.
+					break
+				}
+
+				p, err = f.scopePath(p, rootPath)
+				if err != nil {
+					return nil, err
 				}
-				root.Path = path.Join(root.Path, me.Name)
 			}
 
+			root.Path = p
 			root.Object = pivot
-			parts = parts[1:]
 		}
 	}
 
-	f.recurser.TraverseLeafs = tl
-	es, err := f.recurser.Recurse(ctx, root, parts)
+	r := f.recurser
+	r.TraverseLeafs = tl
+	r.Properties = props
+	r.Exclude = f.excludePaths(base)
+
+	var es []list.Element
+	err := f.retry(ctx, func() error {
+		var rerr error
+		es, rerr = r.Recurse(ctx, root, parts)
+		return rerr
+	})
 	if err != nil {
 		return nil, err
 	}
 
+	if f.sorted || f.firstMatch {
+		// Stable ordering such that the first match is the same across calls
+		sort.Sort(byPath(es))
+	}
+
 	return es, nil
 }
 
-func (f *Finder) datacenter() (*object.Datacenter, error) {
-	if f.dc == nil {
-		return nil, errors.New("please specify a datacenter")
+// findPaths is like findWithProperties, but combines the results of each of the given paths,
+// omitting elements that refer to a managed object already matched by an earlier path.
+// If no path is given, "*" is used.
+func (f *Finder) findPaths(ctx context.Context, fn findRelativeFunc, tl bool, props map[string][]string, paths []string) ([]list.Element, error) {
+	if len(paths) == 0 {
+		paths = []string{"*"}
 	}
 
-	return f.dc, nil
-}
+	var es []list.Element
 
-// datacenterPath returns the absolute path to the Datacenter containing the given ref
-func (f *Finder) datacenterPath(ctx context.Context, ref types.ManagedObjectReference) (string, error) {
-	mes, err := mo.Ancestors(ctx, f.client, f.client.ServiceContent.PropertyCollector, ref)
-	if err != nil {
-		return "", err
+	for _, p := range paths {
+		pes, err := f.findWithProperties(ctx, fn, tl, props, p)
+		if err != nil {
+			return nil, err
+		}
+
+		es = append(es, pes...)
 	}
 
-	// Chop leaves under the Datacenter
-	for i := len(mes) - 1; i > 0; i-- {
-		if mes[i].Self.Type == "Datacenter" {
-			break
-		}
-		mes = mes[:i]
+	es = dedupe(es)
+
+	if len(paths) > 1 && (f.sorted || f.firstMatch) {
+		sort.Sort(byPath(es))
 	}
 
-	var p string
+	return es, nil
+}
 
-	for _, me := range mes {
-		// Skip root entity in building inventory path.
-		if me.Parent == nil {
+// joinPaths returns the given paths as shown in errors, where no path means "*".
+func joinPaths(paths []string) string {
+	if len(paths) == 0 {
+		return "*"
+	}
+
+	return strings.Join(paths, " ")
+}
+
+// dedupe removes elements referring to the same managed object, keeping the first occurrence.
+func dedupe(es []list.Element) []list.Element {
+	seen := make(map[types.ManagedObjectReference]bool, len(es))
+	out := es[:0]
+
+	for _, e := range es {
+		ref := e.Object.Reference()
+		if seen[ref] {
 			continue
 		}
-
-		p = p + "/" + me.Name
+		seen[ref] = true
+		out = append(out, e)
 	}
 
-	return p, nil
+	return out
 }
 
-func (f *Finder) dcFolders(ctx context.Context) (*object.DatacenterFolders, error) {
-	if f.folders != nil {
-		return f.folders, nil
+// isDatacenterPath returns true if the given relative path parts start with a datacenter segment
+// followed by the name of one of the datacenter folders.
+func isDatacenterPath(parts []string) bool {
+	if len(parts) < 2 {
+		return false
 	}
 
-	dc, err := f.datacenter()
-	if err != nil {
-		return nil, err
+	switch parts[1] {
+	case "vm", "host", "datastore", "network":
+		return true
+	default:
+		return false
 	}
+}
+
+type byPath []list.Element
 
-	folders, err := dc.Folders(ctx)
+func (s byPath) Len() int           { return len(s) }
+func (s byPath) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s byPath) Less(i, j int) bool { return s[i].Path < s[j].Path }
+
+// findAll returns all elements below the folder returned by fn, descending into any child folders,
+// including StoragePod folders.  Folders themselves are not included in the result.
+func (f *Finder) findAll(ctx context.Context, fn findRelativeFunc) ([]list.Element, error) {
+	es, err := f.find(ctx, fn, true, ".")
 	if err != nil {
 		return nil, err
 	}
 
-	f.folders = folders
+	r := f.recurser
+	r.TraverseLeafs = true
 
-	return f.folders, nil
-}
+	var all []list.Element
+	for len(es) > 0 {
+		e := es[0]
+		es = es[1:]
 
-func (f *Finder) dcReference(_ context.Context) (object.Reference, error) {
-	dc, err := f.datacenter()
-	if err != nil {
-		return nil, err
+		switch e.Object.Reference().Type {
+		case "Folder", "StoragePod":
+		default:
+			all = append(all, e)
+			continue
+		}
+
+		var children []list.Element
+		err = f.retry(ctx, func() error {
+			var rerr error
+			children, rerr = r.Recurse(ctx, e, nil)
+			return rerr
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		es = append(es, children...)
 	}
 
-	return dc, nil
+	return all, nil
 }
 
-func (f *Finder) vmFolder(ctx context.Context) (object.Reference, error) {
-	folders, err := f.dcFolders(ctx)
-	if err != nil {
-		return nil, err
+func (f *Finder) datacenter() (*object.Datacenter, error) {
+	if f.dc == nil {
+		return nil, errors.New("please specify a datacenter")
 	}
 
-	return folders.VmFolder, nil
+	return f.dc, nil
 }
 
-func (f *Finder) hostFolder(ctx context.Context) (object.Reference, error) {
-	folders, err := f.dcFolders(ctx)
-	if err != nil {
-		return nil, err
+// ancestry is the name and parent of a set of managed entities and all of their ancestors, as returned
+// by Finder.ancestors.  Paths are joined once per entity, such that shared ancestors are only walked once.
+type ancestry struct {
+	names   map[types.ManagedObjectReference]string
+	parents map[types.ManagedObjectReference]types.ManagedObjectReference
+	paths   map[types.ManagedObjectReference]string
+}
+
+// path returns the absolute inventory path of ref, which must be one of the entities of the ancestry.
+func (a *ancestry) path(ref types.ManagedObjectReference) (string, error) {
+	if p, ok := a.paths[ref]; ok {
+		return p, nil
 	}
 
-	return folders.HostFolder, nil
-}
+	name, ok := a.names[ref]
+	if !ok {
+		return "", &NotFoundError{ref.Type, ref.Value}
+	}
 
-func (f *Finder) datastoreFolder(ctx context.Context) (object.Reference, error) {
-	folders, err := f.dcFolders(ctx)
-	if err != nil {
-		return nil, err
+	p := "/"
+	if parent, ok := a.parents[ref]; ok {
+		// Skip root entity in building inventory path.
+		pp, err := a.path(parent)
+		if err != nil {
+			return "", err
+		}
+		p = path.Join(pp, name)
 	}
 
-	return folders.DatastoreFolder, nil
+	a.paths[ref] = p
+	return p, nil
 }
 
-func (f *Finder) networkFolder(ctx context.Context) (object.Reference, error) {
-	folders, err := f.dcFolders(ctx)
+// ancestors retrieves the name and parent of the given entities and all of their ancestors,
+// in a single property collector request.  VMs within a vApp have the vApp as their parent.
+func (f *Finder) ancestors(ctx context.Context, refs []types.ManagedObjectReference) (*ancestry, error) {
+	a := &ancestry{
+		names:   make(map[types.ManagedObjectReference]string),
+		parents: make(map[types.ManagedObjectReference]types.ManagedObjectReference),
+		paths:   make(map[types.ManagedObjectReference]string),
+	}
+
+	if len(refs) == 0 {
+		return a, nil
+	}
+
+	spec := types.PropertyFilterSpec{
+		PropSet: []types.PropertySpec{
+			{
+				Type:    "ManagedEntity",
+				PathSet: []string{"name", "parent"},
+			},
+			{
+				Type:    "VirtualMachine",
+				PathSet: []string{"parentVApp"},
+			},
+		},
+	}
+
+	seen := make(map[types.ManagedObjectReference]bool)
+
+	for _, ref := range refs {
+		if seen[ref] {
+			continue
+		}
+		seen[ref] = true
+
+		spec.ObjectSet = append(spec.ObjectSet, types.ObjectSpec{
+			Obj: ref,
+			SelectSet: []types.BaseSelectionSpec{
+				&types.TraversalSpec{
+					SelectionSpec: types.SelectionSpec{Name: "traverseParent"},
+					Type:          "ManagedEntity",
+					Path:          "parent",
+					SelectSet: []types.BaseSelectionSpec{
+						&types.SelectionSpec{Name: "traverseParent"},
+					},
+				},
+				&types.TraversalSpec{
+					Type: "VirtualMachine",
+					Path: "parentVApp",
+					SelectSet: []types.BaseSelectionSpec{
+						&types.SelectionSpec{Name: "traverseParent"},
+					},
+				},
+			},
+		})
+	}
+
+	req := types.RetrieveProperties{
+		SpecSet: []types.PropertyFilterSpec{spec},
+	}
+
+	res, err := f.retrieveProperties(ctx, req)
 	if err != nil {
 		return nil, err
 	}
 
-	return folders.NetworkFolder, nil
-}
+	for _, oc := range res.Returnval {
+		for _, p := range oc.PropSet {
+			switch val := p.Val.(type) {
+			case string:
+				if p.Name == "name" {
+					a.names[oc.Obj] = val
+				}
+			case types.ManagedObjectReference:
+				if p.Name == "parent" || p.Name == "parentVApp" {
+					a.parents[oc.Obj] = val
+				}
+			}
+		}
+	}
 
-func (f *Finder) rootFolder(_ context.Context) (object.Reference, error) {
-	return object.NewRootFolder(f.client), nil
+	return a, nil
 }
 
-func (f *Finder) managedObjectList(ctx context.Context, path string, tl bool) ([]list.Element, error) {
-	fn := f.rootFolder
+// inventoryPaths returns the absolute inventory path of each of the given refs, with the ancestors
+// of all refs retrieved in a single property collector request.
+func (f *Finder) inventoryPaths(ctx context.Context, refs []types.ManagedObjectReference) (map[types.ManagedObjectReference]string, error) {
+	a, err := f.ancestors(ctx, refs)
+	if err != nil {
+		return nil, err
+	}
 
-	if f.dc != nil {
-		fn = f.dcReference
+	paths := make(map[types.ManagedObjectReference]string, len(refs))
+
+	for _, ref := range refs {
+		if paths[ref], err = a.path(ref); err != nil {
+			return nil, err
+		}
 	}
 
-	if len(path) == 0 {
-		path = "."
+	return paths, nil
+}
+
+// inventoryPath returns the absolute inventory path of the given ref, built from its ancestors.
+func (f *Finder) inventoryPath(ctx context.Context, ref types.ManagedObjectReference) (string, error) {
+	paths, err := f.inventoryPaths(ctx, []types.ManagedObjectReference{ref})
+	if err != nil {
+		return "", err
 	}
 
-	return f.find(ctx, fn, tl, path)
+	return paths[ref], nil
 }
 
-// Element returns an Element for the given ManagedObjectReference
-// This method is only useful for looking up the InventoryPath of a ManagedObjectReference.
-func (f *Finder) Element(ctx context.Context, ref types.ManagedObjectReference) (*list.Element, error) {
-	rl := func(_ context.Context) (object.Reference, error) {
-		return ref, nil
+// displayPaths is like inventoryPaths, but the paths are rendered relative to the root configured with SetRoot, if any,
+// as the InventoryPath of objects returned by the Finder are.
+func (f *Finder) displayPaths(ctx context.Context, refs []types.ManagedObjectReference) (map[types.ManagedObjectReference]string, error) {
+	paths, err := f.inventoryPaths(ctx, refs)
+	if err != nil || f.root == nil {
+		return paths, err
 	}
 
-	e, err := f.find(ctx, rl, false, ".")
+	rootPath, err := f.scopedRootPath(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	if len(e) == 0 {
-		return nil, &NotFoundError{ref.Type, ref.Value}
+	for ref, p := range paths {
+		if paths[ref], err = f.scopePath(p, rootPath); err != nil {
+			return nil, err
+		}
 	}
 
-	if len(e) > 1 {
-		panic("ManagedObjectReference must be unique")
+	return paths, nil
+}
+
+// datacenterPath returns the absolute path to the Datacenter containing the given ref,
+// or an empty string if ref is not within a Datacenter.
+func (f *Finder) datacenterPath(ctx context.Context, ref types.ManagedObjectReference) (string, error) {
+	a, err := f.ancestors(ctx, []types.ManagedObjectReference{ref})
+	if err != nil {
+		return "", err
+	}
+
+	for ok := true; ok; ref, ok = a.parents[ref] {
+		if ref.Type == "Datacenter" {
+			return a.path(ref)
+		}
+	}
+
+	return "", nil
+}
+
+// datacenterKey is the context key for a datacenter inferred from a path, see findWithProperties.
+type datacenterKey struct{}
+
+func (f *Finder) dcFolders(ctx context.Context) (*object.DatacenterFolders, error) {
+	if dc, ok := ctx.Value(datacenterKey{}).(*object.Datacenter); ok {
+		return f.datacenterFolders(ctx, dc)
+	}
+
+	if f.folders != nil {
+		return f.folders, nil
+	}
+
+	dc, err := f.datacenter()
+	if err != nil {
+		return nil, err
+	}
+
+	folders, err := f.datacenterFolders(ctx, dc)
+	if err != nil {
+		return nil, err
+	}
+
+	f.folders = folders
+
+	return f.folders, nil
+}
+
+// datacenterFolders is like object.Datacenter.Folders, but retrieves the folders from the Finder's source.
+func (f *Finder) datacenterFolders(ctx context.Context, dc *object.Datacenter) (*object.DatacenterFolders, error) {
+	var md mo.Datacenter
+
+	err := f.retrieveOne(ctx, dc.Reference(), []string{"name", "vmFolder", "hostFolder", "datastoreFolder", "networkFolder"}, &md)
+	if err != nil {
+		return nil, err
+	}
+
+	folders := &object.DatacenterFolders{
+		VmFolder:        object.NewFolder(f.client, md.VmFolder),
+		HostFolder:      object.NewFolder(f.client, md.HostFolder),
+		DatastoreFolder: object.NewFolder(f.client, md.DatastoreFolder),
+		NetworkFolder:   object.NewFolder(f.client, md.NetworkFolder),
+	}
+
+	folders.VmFolder.InventoryPath = path.Join("/", md.Name, "vm")
+	folders.HostFolder.InventoryPath = path.Join("/", md.Name, "host")
+	folders.DatastoreFolder.InventoryPath = path.Join("/", md.Name, "datastore")
+	folders.NetworkFolder.InventoryPath = path.Join("/", md.Name, "network")
+
+	return folders, nil
+}
+
+func (f *Finder) dcReference(ctx context.Context) (object.Reference, error) {
+	if dc, ok := ctx.Value(datacenterKey{}).(*object.Datacenter); ok {
+		return dc, nil
+	}
+
+	dc, err := f.datacenter()
+	if err != nil {
+		return nil, err
+	}
+
+	return dc, nil
+}
+
+func (f *Finder) vmFolder(ctx context.Context) (object.Reference, error) {
+	folders, err := f.dcFolders(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return folders.VmFolder, nil
+}
+
+func (f *Finder) hostFolder(ctx context.Context) (object.Reference, error) {
+	folders, err := f.dcFolders(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return folders.HostFolder, nil
+}
+
+func (f *Finder) datastoreFolder(ctx context.Context) (object.Reference, error) {
+	folders, err := f.dcFolders(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return folders.DatastoreFolder, nil
+}
+
+func (f *Finder) networkFolder(ctx context.Context) (object.Reference, error) {
+	folders, err := f.dcFolders(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return folders.NetworkFolder, nil
+}
+
+func (f *Finder) rootFolder(_ context.Context) (object.Reference, error) {
+	return object.NewRootFolder(f.client), nil
+}
+
+func (f *Finder) managedObjectList(ctx context.Context, path string, tl bool) ([]list.Element, error) {
+	fn := f.rootFolder
+
+	if f.dc != nil {
+		fn = f.dcReference
+	}
+
+	if len(path) == 0 {
+		path = "."
+	}
+
+	return f.find(ctx, fn, tl, path)
+}
+
+// Element returns an Element for the given ManagedObjectReference
+// This method is only useful for looking up the InventoryPath of a ManagedObjectReference.
+func (f *Finder) Element(ctx context.Context, ref types.ManagedObjectReference) (*list.Element, error) {
+	rl := func(_ context.Context) (object.Reference, error) {
+		return ref, nil
+	}
+
+	e, err := f.find(ctx, rl, false, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	if len(e) == 0 {
+		return nil, &NotFoundError{ref.Type, ref.Value}
+	}
+
+	if len(e) > 1 {
+		panic("ManagedObjectReference must be unique")
+	}
+
+	return &e[0], nil
+}
+
+// InventoryPath returns the full inventory path of the given object, such as "/dc/vm/folder/name",
+// built by walking its ancestors.  The path is rendered relative to the root configured with SetRoot, if any.
+func (f *Finder) InventoryPath(ctx context.Context, ref object.Reference) (string, error) {
+	e, err := f.Element(ctx, ref.Reference())
+	if err != nil {
+		return "", err
+	}
+
+	return e.Path, nil
+}
+
+// Names returns the name of each of the given objects, which may be of different types,
+// retrieved in a single property collector request.
+func (f *Finder) Names(ctx context.Context, refs []types.ManagedObjectReference) (map[types.ManagedObjectReference]string, error) {
+	names := make(map[types.ManagedObjectReference]string, len(refs))
+
+	if len(refs) == 0 {
+		return names, nil
+	}
+
+	var spec types.PropertyFilterSpec
+	seen := make(map[types.ManagedObjectReference]bool)
+	kinds := make(map[string]bool)
+
+	for _, ref := range refs {
+		if seen[ref] {
+			continue
+		}
+		seen[ref] = true
+
+		spec.ObjectSet = append(spec.ObjectSet, types.ObjectSpec{Obj: ref})
+
+		if !kinds[ref.Type] {
+			kinds[ref.Type] = true
+			spec.PropSet = append(spec.PropSet, types.PropertySpec{
+				Type:    ref.Type,
+				PathSet: []string{"name"},
+			})
+		}
+	}
+
+	req := types.RetrieveProperties{
+		SpecSet: []types.PropertyFilterSpec{spec},
+	}
+
+	res, err := f.retrieveProperties(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, oc := range res.Returnval {
+		for _, p := range oc.PropSet {
+			if p.Name == "name" {
+				names[oc.Obj], _ = p.Val.(string)
+			}
+		}
+	}
+
+	return names, nil
+}
+
+// ObjectReference converts the given ManagedObjectReference to a type from the object package via object.NewReference
+// with the object.Common.InventoryPath field set.
+func (f *Finder) ObjectReference(ctx context.Context, ref types.ManagedObjectReference) (object.Reference, error) {
+	e, err := f.Element(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	r := object.NewReference(f.client, ref)
+
+	type common interface {
+		SetInventoryPath(string)
+	}
+
+	r.(common).SetInventoryPath(e.Path)
+
+	if ds, ok := r.(*object.Datastore); ok {
+		if f.dc != nil {
+			ds.DatacenterPath = f.dc.InventoryPath
+		} else {
+			ds.DatacenterPath, err = f.datacenterPath(ctx, ref)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return r, nil
+}
+
+// ResolveMoref is like ObjectReference, but takes a ManagedObjectReference in its string form,
+// for example "VirtualMachine:vm-123", as received from an API or command line.
+func (f *Finder) ResolveMoref(ctx context.Context, moref string) (object.Reference, error) {
+	var ref types.ManagedObjectReference
+
+	if !ref.FromString(moref) {
+		return nil, fmt.Errorf("invalid managed object reference '%s'", moref)
+	}
+
+	return f.ObjectReference(ctx, ref)
+}
+
+// InventoryPathToObject resolves the given absolute inventory path to an object, descending from
+// the root folder one path component at a time.  Unlike the other Finder methods, components are
+// matched by literal equality, so names containing glob metacharacters are resolved as-is.
+// Unlike SearchIndex.FindByInventoryPath, only the property collector is used.
+func (f *Finder) InventoryPathToObject(ctx context.Context, p string) (object.Reference, error) {
+	e := list.Element{
+		Path:   "/",
+		Object: object.NewRootFolder(f.client),
+	}
+
+	var dc string
+
+	for _, name := range strings.Split(strings.Trim(p, "/"), "/") {
+		if name == "" {
+			continue
+		}
+
+		l := list.Lister{
+			Collector: f.recurser.Collector,
+			Reference: e.Object.Reference(),
+			Prefix:    e.Path,
+			Source:    f.recurser.Source,
+		}
+
+		if e.Object.Reference().Type == "Datacenter" {
+			dc = e.Path
+		}
+
+		children, err := l.List(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		found := false
+		for _, child := range children {
+			if path.Base(child.Path) == name {
+				e = child
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return nil, &NotFoundError{"object", p}
+		}
+	}
+
+	r := object.NewReference(f.client, e.Object.Reference())
+
+	type common interface {
+		SetInventoryPath(string)
+	}
+
+	r.(common).SetInventoryPath(e.Path)
+
+	if ds, ok := r.(*object.Datastore); ok {
+		ds.DatacenterPath = dc
+	}
+
+	return r, nil
+}
+
+func (f *Finder) ManagedObjectList(ctx context.Context, path string) ([]list.Element, error) {
+	return f.managedObjectList(ctx, path, false)
+}
+
+// ManagedObjectNodes is like ManagedObjectList, but returns each element as an InventoryNode,
+// which has a stable JSON encoding independent of list.Element.
+func (f *Finder) ManagedObjectNodes(ctx context.Context, path string) ([]InventoryNode, error) {
+	es, err := f.ManagedObjectList(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make([]InventoryNode, len(es))
+	for i, e := range es {
+		nodes[i] = ToInventoryNode(e)
+	}
+
+	return nodes, nil
+}
+
+// ResolvePath returns the object matching the given path, which may be relative, along with its
+// fully-qualified inventory path.  The object is converted via object.NewReference with its
+// InventoryPath field set to the same path.
+func (f *Finder) ResolvePath(ctx context.Context, p string) (object.Reference, string, error) {
+	es, err := f.ManagedObjectList(ctx, p)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if len(es) == 0 {
+		return nil, "", &NotFoundError{"object", p}
+	}
+
+	if len(es) > 1 && !f.firstMatch {
+		return nil, "", &MultipleFoundError{"object", p}
+	}
+
+	e := es[0]
+	r := object.NewReference(f.client, e.Object.Reference())
+
+	type common interface {
+		SetInventoryPath(string)
+	}
+
+	r.(common).SetInventoryPath(e.Path)
+
+	return r, e.Path, nil
+}
+
+// ManagedObjectMap is like ManagedObjectList, but returns the elements keyed by managed object reference.
+func (f *Finder) ManagedObjectMap(ctx context.Context, path ...string) (map[types.ManagedObjectReference]list.Element, error) {
+	if len(path) == 0 {
+		path = []string{"*"}
+	}
+
+	m := make(map[types.ManagedObjectReference]list.Element)
+
+	for _, p := range path {
+		es, err := f.ManagedObjectList(ctx, p)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, e := range es {
+			ref := e.Object.Reference()
+			if _, ok := m[ref]; !ok {
+				m[ref] = e
+			}
+		}
+	}
+
+	return m, nil
+}
+
+func (f *Finder) ManagedObjectListChildren(ctx context.Context, path string) ([]list.Element, error) {
+	return f.managedObjectList(ctx, path, true)
+}
+
+// ListChildrenFrom lists the children of the given ref, which must be a container such as a
+// Folder or ResourcePool.  If path elements are given, they are joined and matched relative
+// to ref instead of listing its immediate children.
+func (f *Finder) ListChildrenFrom(ctx context.Context, ref object.Reference, p ...string) ([]list.Element, error) {
+	fn := func(_ context.Context) (object.Reference, error) {
+		return ref, nil
+	}
+
+	if len(p) == 0 {
+		p = []string{"*"}
+	}
+
+	return f.find(ctx, fn, false, path.Join(append([]string{"."}, p...)...))
+}
+
+// WalkFunc is called by Walk for each element visited.
+// If descend is false, the children of a container element are not visited.
+// If err is not nil, Walk stops and returns the error.
+type WalkFunc func(e list.Element) (descend bool, err error)
+
+// Walk performs a depth-first traversal of the inventory starting at root, calling visit for
+// root and each of its descendants.  Only the children of containers for which visit returns
+// descend=true are retrieved.
+func (f *Finder) Walk(ctx context.Context, root object.Reference, visit WalkFunc) error {
+	fn := func(_ context.Context) (object.Reference, error) {
+		return root, nil
+	}
+
+	es, err := f.find(ctx, fn, false, ".")
+	if err != nil {
+		return err
+	}
+
+	for _, e := range es {
+		if err = f.walk(ctx, e, visit); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (f *Finder) walk(ctx context.Context, e list.Element, visit WalkFunc) error {
+	descend, err := visit(e)
+	if err != nil {
+		return err
+	}
+
+	if !descend || !isContainer(e.Object.Reference()) {
+		return nil
+	}
+
+	l := list.Lister{
+		Collector:  f.recurser.Collector,
+		Reference:  e.Object.Reference(),
+		Prefix:     e.Path,
+		All:        f.recurser.All,
+		SkipHidden: f.recurser.SkipHidden,
+		Source:     f.recurser.Source,
+	}
+
+	var children []list.Element
+	err = f.retry(ctx, func() error {
+		var rerr error
+		children, rerr = l.List(ctx)
+		return rerr
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, child := range children {
+		if err = f.walk(ctx, child, visit); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ElementContext is a list.Element along with details needed to render it as a node in an inventory tree.
+type ElementContext struct {
+	list.Element
+
+	Type        string // Managed object type, e.g. "Folder"
+	Name        string // Base name of the inventory path
+	IsContainer bool   // True if the object can be listed
+	Children    int    // Number of children, if IsContainer is true
+}
+
+// HasChildren returns true if the element is a container with at least one child.
+func (e ElementContext) HasChildren() bool {
+	return e.Children != 0
+}
+
+func isContainer(ref types.ManagedObjectReference) bool {
+	switch ref.Type {
+	case "Folder", "StoragePod", "Datacenter", "ComputeResource", "ClusterComputeResource",
+		"ResourcePool", "HostSystem", "VirtualApp":
+		return true
+	default:
+		return false
+	}
+}
+
+// Complete returns the paths of the children of the partial path's parent that start with its last segment,
+// for use in shell completion.  Container children have a trailing "/", as with file system completion.
+// If the parent is a pattern, such as "vm/web*/", the completions name each matched container instead.
+func (f *Finder) Complete(ctx context.Context, partial string) ([]string, error) {
+	dir, prefix := "", partial
+	if i := strings.LastIndex(partial, "/"); i >= 0 {
+		dir, prefix = partial[:i+1], partial[i+1:]
+	}
+
+	es, err := f.ManagedObjectListChildren(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var containers map[string]bool
+
+	if isPattern(dir) {
+		// Complete only the children of the containers matched by dir, not the other objects it matches
+		cs, err := f.ManagedObjectList(ctx, dir)
+		if err != nil {
+			return nil, err
+		}
+
+		containers = make(map[string]bool)
+		for _, c := range cs {
+			if isContainer(c.Object.Reference()) {
+				containers[c.Path] = true
+			}
+		}
+	}
+
+	var paths []string
+	for _, e := range es {
+		name := path.Base(e.Path)
+		if !strings.HasPrefix(name, prefix) || (containers != nil && !containers[path.Dir(e.Path)]) {
+			continue
+		}
+
+		p := completionDir(dir, e.Path) + name
+		if isContainer(e.Object.Reference()) {
+			p += "/"
+		}
+
+		paths = append(paths, p)
+	}
+
+	sort.Strings(paths)
+
+	return paths, nil
+}
+
+// isPattern returns true if p contains any of the special characters of path.Match.
+func isPattern(p string) bool {
+	return strings.ContainsAny(p, "*?[\\")
+}
+
+// completionDir returns dir, the directory of a partial path given to Complete, with any patterns
+// replaced by the names of the corresponding ancestors of the element at p, such that completions
+// name the matched containers rather than repeat the pattern.
+func completionDir(dir, p string) string {
+	if !isPattern(dir) {
+		return dir
+	}
+
+	parent := path.Dir(p)
+
+	if strings.HasPrefix(dir, "/") {
+		return strings.TrimSuffix(parent, "/") + "/"
+	}
+
+	n := len(strings.Split(path.Clean(dir), "/"))
+	segs := strings.Split(strings.TrimPrefix(parent, "/"), "/")
+	if n > len(segs) {
+		return dir
+	}
+
+	rel := strings.Join(segs[len(segs)-n:], "/") + "/"
+	if strings.HasPrefix(dir, "./") {
+		rel = "./" + rel
+	}
+
+	return rel
+}
+
+// childProperties are the properties that reference the children of each type of container.
+var childProperties = map[string][]string{
+	"Folder":                 {"childEntity"},
+	"StoragePod":             {"childEntity"},
+	"Datacenter":             {"vmFolder", "hostFolder", "datastoreFolder", "networkFolder"},
+	"ComputeResource":        {"host", "resourcePool"},
+	"ClusterComputeResource": {"host", "resourcePool"},
+	"ResourcePool":           {"resourcePool"},
+	"HostSystem":             {"datastore", "network", "vm"},
+	"VirtualApp":             {"resourcePool", "vm"},
+}
+
+// childCount returns the number of children referenced by the childProperties of o.
+func childCount(o interface{}) int {
+	switch o := o.(type) {
+	case mo.Folder:
+		return len(o.ChildEntity)
+	case mo.StoragePod:
+		return len(o.ChildEntity)
+	case mo.Datacenter:
+		n := 0
+		for _, ref := range []types.ManagedObjectReference{o.VmFolder, o.HostFolder, o.DatastoreFolder, o.NetworkFolder} {
+			if ref.Value != "" {
+				n++
+			}
+		}
+		return n
+	case mo.ComputeResource:
+		return childCount(mo.ClusterComputeResource{ComputeResource: o})
+	case mo.ClusterComputeResource:
+		n := len(o.Host)
+		if o.ResourcePool != nil {
+			n++
+		}
+		return n
+	case mo.ResourcePool:
+		return len(o.ResourcePool)
+	case mo.HostSystem:
+		return len(o.Datastore) + len(o.Network) + len(o.Vm)
+	case mo.VirtualApp:
+		return len(o.ResourcePool.ResourcePool) + len(o.Vm)
+	default:
+		return 0
+	}
+}
+
+// ManagedObjectListContext is like ManagedObjectListChildren, but also returns the type, name
+// and number of children for each element.  Children are counted from the properties that reference them,
+// retrieved along with the elements, and so include hidden folders.
+func (f *Finder) ManagedObjectListContext(ctx context.Context, p string) ([]ElementContext, error) {
+	fn := f.rootFolder
+
+	if f.dc != nil {
+		fn = f.dcReference
+	}
+
+	if len(p) == 0 {
+		p = "."
+	}
+
+	es, err := f.findWithProperties(ctx, fn, true, childProperties, p)
+	if err != nil {
+		return nil, err
+	}
+
+	var ecs []ElementContext
+
+	for _, e := range es {
+		ref := e.Object.Reference()
+
+		ec := ElementContext{
+			Element:     e,
+			Type:        ref.Type,
+			Name:        path.Base(e.Path),
+			IsContainer: isContainer(ref),
+		}
+
+		if ec.IsContainer {
+			ec.Children = childCount(e.Object)
+		}
+
+		ecs = append(ecs, ec)
+	}
+
+	return ecs, nil
+}
+
+func (f *Finder) DatacenterList(ctx context.Context, paths ...string) ([]*object.Datacenter, error) {
+	es, err := f.findPaths(ctx, f.rootFolder, false, nil, paths)
+	if err != nil {
+		return nil, err
+	}
+
+	var dcs []*object.Datacenter
+	for _, e := range es {
+		ref := e.Object.Reference()
+		if ref.Type == "Datacenter" {
+			dc := object.NewDatacenter(f.client, ref)
+			dc.InventoryPath = e.Path
+			dcs = append(dcs, dc)
+		}
+	}
+
+	if len(dcs) != 0 && len(f.datacenterPrivileges) != 0 {
+		dcs, err = f.privilegedDatacenters(ctx, dcs)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(dcs) == 0 {
+		return nil, &NotFoundError{"datacenter", joinPaths(paths)}
+	}
+
+	sort.Sort(datacentersByName(dcs))
+
+	if f.preferredDatacenter != "" {
+		for i, dc := range dcs {
+			if dc.Name() == f.preferredDatacenter || dc.InventoryPath == f.preferredDatacenter {
+				copy(dcs[1:i+1], dcs[:i])
+				dcs[0] = dc
+				break
+			}
+		}
+	}
+
+	return dcs, nil
+}
+
+// privilegedDatacenters returns the datacenters on which the user of the current session has all of the
+// privileges configured via SetDatacenterPrivileges, checked with a single HasPrivilegeOnEntities call.
+func (f *Finder) privilegedDatacenters(ctx context.Context, dcs []*object.Datacenter) ([]*object.Datacenter, error) {
+	var sm mo.SessionManager
+
+	err := f.retrieveOne(ctx, *f.client.ServiceContent.SessionManager, []string{"currentSession"}, &sm)
+	if err != nil {
+		return nil, err
+	}
+
+	if sm.CurrentSession == nil {
+		return nil, errors.New("no current session")
+	}
+
+	refs := make([]types.ManagedObjectReference, len(dcs))
+	for i, dc := range dcs {
+		refs[i] = dc.Reference()
+	}
+
+	privs, err := object.NewAuthorizationManager(f.client).HasPrivilegeOnEntities(ctx, refs, sm.CurrentSession.Key, f.datacenterPrivileges)
+	if err != nil {
+		return nil, err
+	}
+
+	granted := make(map[types.ManagedObjectReference]bool)
+
+	for _, p := range privs {
+		granted[p.Entity] = len(p.PrivAvailability) != 0
+		for _, a := range p.PrivAvailability {
+			if !a.IsGranted {
+				granted[p.Entity] = false
+			}
+		}
+	}
+
+	var out []*object.Datacenter
+	for _, dc := range dcs {
+		if granted[dc.Reference()] {
+			out = append(out, dc)
+		}
+	}
+
+	return out, nil
+}
+
+// DatacenterFolderState reports which of the four folder roots a datacenter exposes,
+// as returned by DatacenterListWithFolders.
+type DatacenterFolderState struct {
+	Datacenter      *object.Datacenter
+	VmFolder        bool
+	HostFolder      bool
+	DatastoreFolder bool
+	NetworkFolder   bool
+}
+
+// Complete returns true if the datacenter exposes all four folder roots.
+func (s DatacenterFolderState) Complete() bool {
+	return s.VmFolder && s.HostFolder && s.DatastoreFolder && s.NetworkFolder
+}
+
+// DatacenterListWithFolders is like DatacenterList, but also reports which folder roots each datacenter exposes,
+// retrieved for all datacenters in a single property collector request.  Tools can use this to skip
+// misconfigured datacenters up front, rather than failing when their folders are first needed.
+func (f *Finder) DatacenterListWithFolders(ctx context.Context, paths ...string) ([]DatacenterFolderState, error) {
+	dcs, err := f.DatacenterList(ctx, paths...)
+	if err != nil {
+		return nil, err
+	}
+
+	refs := make([]types.ManagedObjectReference, len(dcs))
+	for i, dc := range dcs {
+		refs[i] = dc.Reference()
+	}
+
+	var mdcs []mo.Datacenter
+
+	err = f.retrieve(ctx, refs, []string{"vmFolder", "hostFolder", "datastoreFolder", "networkFolder"}, &mdcs)
+	if err != nil {
+		return nil, err
+	}
+
+	folders := make(map[types.ManagedObjectReference]mo.Datacenter, len(mdcs))
+	for _, mdc := range mdcs {
+		folders[mdc.Self] = mdc
+	}
+
+	states := make([]DatacenterFolderState, len(dcs))
+	for i, dc := range dcs {
+		mdc := folders[dc.Reference()]
+
+		states[i] = DatacenterFolderState{
+			Datacenter:      dc,
+			VmFolder:        mdc.VmFolder.Value != "",
+			HostFolder:      mdc.HostFolder.Value != "",
+			DatastoreFolder: mdc.DatastoreFolder.Value != "",
+			NetworkFolder:   mdc.NetworkFolder.Value != "",
+		}
+	}
+
+	return states, nil
+}
+
+type datacentersByName []*object.Datacenter
+
+func (s datacentersByName) Len() int      { return len(s) }
+func (s datacentersByName) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+
+func (s datacentersByName) Less(i, j int) bool {
+	// Datacenters in different folders can have the same name
+	if s[i].Name() == s[j].Name() {
+		return s[i].InventoryPath < s[j].InventoryPath
+	}
+	return s[i].Name() < s[j].Name()
+}
+
+// ForEachDatacenter calls fn for each datacenter matching path, along with a Finder scoped to that
+// datacenter.  Each scoped Finder shares the client and settings of f, but has its own datacenter,
+// folder cache and VirtualMachineByInstanceUuid cache.  If fn returns an error, iteration stops and the error is returned.
+func (f *Finder) ForEachDatacenter(ctx context.Context, path string, fn func(*object.Datacenter, *Finder) error) error {
+	if path == "" {
+		path = "*"
+	}
+
+	dcs, err := f.DatacenterList(ctx, path)
+	if err != nil {
+		return err
+	}
+
+	for _, dc := range dcs {
+		df := *f
+		df.instanceUuids = new(uuidCache)
+		df.SetDatacenter(dc)
+
+		if err = fn(dc, &df); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (f *Finder) Datacenter(ctx context.Context, path string) (*object.Datacenter, error) {
+	dcs, err := f.DatacenterList(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(dcs) > 1 && !f.firstMatch {
+		return nil, &MultipleFoundError{"datacenter", path}
+	}
+
+	return dcs[0], nil
+}
+
+func (f *Finder) DefaultDatacenter(ctx context.Context) (*object.Datacenter, error) {
+	dc, err := f.resolver.DefaultDatacenter(ctx, f)
+	if err != nil {
+		return nil, toDefaultError(err)
+	}
+
+	return dc, nil
+}
+
+func (f *Finder) DatacenterOrDefault(ctx context.Context, path string) (*object.Datacenter, error) {
+	if path != "" {
+		dc, err := f.Datacenter(ctx, path)
+		if err != nil {
+			return nil, err
+		}
+		return dc, nil
+	}
+
+	return f.DefaultDatacenter(ctx)
+}
+
+func (f *Finder) DatastoreList(ctx context.Context, paths ...string) ([]*object.Datastore, error) {
+	es, err := f.findPaths(ctx, f.datastoreFolder, false, nil, paths)
+	if err != nil {
+		return nil, err
+	}
+
+	var dss []*object.Datastore
+	for _, e := range es {
+		ref := e.Object.Reference()
+		if ref.Type == "Datastore" {
+			ds, err := f.newDatastore(ctx, e)
+			if err != nil {
+				return nil, err
+			}
+
+			dss = append(dss, ds)
+		}
+	}
+
+	if len(dss) == 0 {
+		return nil, &NotFoundError{"datastore", joinPaths(paths)}
+	}
+
+	return dss, nil
+}
+
+// newDatastore returns a Datastore for the given element, with the DatacenterPath field set.
+func (f *Finder) newDatastore(ctx context.Context, e list.Element) (*object.Datastore, error) {
+	var err error
+	ref := e.Object.Reference()
+
+	ds := object.NewDatastore(f.client, ref)
+	ds.InventoryPath = e.Path
+
+	if f.dc == nil {
+		// In this case SetDatacenter was not called and path is absolute
+		ds.DatacenterPath, err = f.datacenterPath(ctx, ref)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		ds.DatacenterPath = f.dc.InventoryPath
+	}
+
+	return ds, nil
+}
+
+// DatastoreVirtualMachines returns the registered VMs that have files on the given datastore, along with
+// the datastore paths of orphaned VM directories: directories containing a .vmx file that do not contain
+// files of any registered VM.
+func (f *Finder) DatastoreVirtualMachines(ctx context.Context, ds *object.Datastore) ([]*object.VirtualMachine, []string, error) {
+	var dso mo.Datastore
+
+	err := f.retrieveOne(ctx, ds.Reference(), []string{"name", "vm"}, &dso)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var vms []*object.VirtualMachine
+	used := make(map[string]bool)
+
+	if len(dso.Vm) != 0 {
+		var vmos []mo.VirtualMachine
+
+		err = f.retrieve(ctx, dso.Vm, []string{"layoutEx.file"}, &vmos)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		paths, err := f.displayPaths(ctx, dso.Vm)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for _, o := range vmos {
+			vm := object.NewVirtualMachine(f.client, o.Self)
+			vm.InventoryPath = paths[o.Self]
+			vms = append(vms, vm)
+
+			if o.LayoutEx == nil {
+				continue
+			}
+
+			for _, file := range o.LayoutEx.File {
+				var p object.DatastorePath
+				if p.FromString(file.Name) && p.Datastore == dso.Name {
+					used[path.Dir(p.Path)] = true
+				}
+			}
+		}
+	}
+
+	b, err := ds.Browser(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	spec := types.HostDatastoreBrowserSearchSpec{
+		MatchPattern: []string{"*.vmx"},
+	}
+
+	task, err := b.SearchDatastoreSubFolders(ctx, (&object.DatastorePath{Datastore: dso.Name}).String(), &spec)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	info, err := task.WaitForResult(ctx, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var orphans []string
+
+	if res, ok := info.Result.(types.ArrayOfHostDatastoreBrowserSearchResults); ok {
+		for _, r := range res.HostDatastoreBrowserSearchResults {
+			var p object.DatastorePath
+			if len(r.File) == 0 || !p.FromString(r.FolderPath) {
+				continue
+			}
+
+			if !used[path.Clean(strings.TrimSuffix(p.Path, "/"))] {
+				orphans = append(orphans, r.FolderPath)
+			}
+		}
+	}
+
+	return vms, orphans, nil
+}
+
+// VirtualMachineListByDatastore returns the VMs with a virtual disk on the given datastore.
+// If allFiles is true, VMs with any file on the datastore are included, such as their config or swap file.
+func (f *Finder) VirtualMachineListByDatastore(ctx context.Context, ds *object.Datastore, allFiles bool) ([]*object.VirtualMachine, error) {
+	var dso mo.Datastore
+
+	err := f.retrieveOne(ctx, ds.Reference(), []string{"name", "vm"}, &dso)
+	if err != nil {
+		return nil, err
+	}
+
+	var vms []*object.VirtualMachine
+
+	if len(dso.Vm) != 0 {
+		var vmos []mo.VirtualMachine
+
+		err = f.retrieve(ctx, dso.Vm, []string{"layoutEx.file"}, &vmos)
+		if err != nil {
+			return nil, err
+		}
+
+		var refs []types.ManagedObjectReference
+		for _, o := range vmos {
+			if hasDatastoreFile(o, dso.Name, allFiles) {
+				refs = append(refs, o.Self)
+			}
+		}
+
+		paths, err := f.displayPaths(ctx, refs)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, ref := range refs {
+			vm := object.NewVirtualMachine(f.client, ref)
+			vm.InventoryPath = paths[ref]
+			vms = append(vms, vm)
+		}
+	}
+
+	if len(vms) == 0 {
+		return nil, &NotFoundError{"vm", ds.InventoryPath}
+	}
+
+	return vms, nil
+}
+
+// hasDatastoreFile returns true if the given VM has a disk file, or any file if allFiles is true, on the named datastore.
+// The layoutEx.file property must be populated.
+func hasDatastoreFile(vm mo.VirtualMachine, name string, allFiles bool) bool {
+	if vm.LayoutEx == nil {
+		// The datastore's vm property already implies some file is stored there
+		return allFiles
+	}
+
+	for _, file := range vm.LayoutEx.File {
+		var p object.DatastorePath
+		if !p.FromString(file.Name) || p.Datastore != name {
+			continue
+		}
+
+		switch types.VirtualMachineFileLayoutExFileType(file.Type) {
+		case types.VirtualMachineFileLayoutExFileTypeDiskDescriptor, types.VirtualMachineFileLayoutExFileTypeDiskExtent:
+			return true
+		default:
+			if allFiles {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// DatastoreSummary pairs a Datastore with its summary property.
+type DatastoreSummary struct {
+	Datastore *object.Datastore
+	Summary   types.DatastoreSummary
+}
+
+// Shared returns true if the datastore can be accessed by multiple hosts,
+// false for host-local storage.
+func (s DatastoreSummary) Shared() bool {
+	return s.Summary.MultipleHostAccess != nil && *s.Summary.MultipleHostAccess
+}
+
+// datastoreProperties is a Datastore along with the properties retrieved by datastoreList.
+type datastoreProperties struct {
+	ds *object.Datastore
+	mo mo.Datastore
+}
+
+// datastoreList finds the datastores matching any of the given paths, retrieving the given properties
+// in the same property collector request used for traversal.  Only datastores for which match returns
+// true are included, if match is not nil.
+func (f *Finder) datastoreList(ctx context.Context, props []string, match func(mo.Datastore) bool, paths ...string) ([]datastoreProperties, error) {
+	kinds := map[string][]string{
+		"Datastore": props,
+	}
+
+	es, err := f.findPaths(ctx, f.datastoreFolder, false, kinds, paths)
+	if err != nil {
+		return nil, err
+	}
+
+	var dss []datastoreProperties
+	for _, e := range es {
+		switch o := e.Object.(type) {
+		case mo.Datastore:
+			if match != nil && !match(o) {
+				continue
+			}
+
+			ds, err := f.newDatastore(ctx, e)
+			if err != nil {
+				return nil, err
+			}
+
+			dss = append(dss, datastoreProperties{ds, o})
+		}
+	}
+
+	if len(dss) == 0 {
+		return nil, &NotFoundError{"datastore", joinPaths(paths)}
+	}
+
+	return dss, nil
+}
+
+func datastores(dss []datastoreProperties) []*object.Datastore {
+	ds := make([]*object.Datastore, len(dss))
+	for i := range dss {
+		ds[i] = dss[i].ds
+	}
+	return ds
+}
+
+// DatastoreListSummary is like DatastoreList, but also returns the summary of each datastore,
+// retrieved along with the datastores in a single property collector request.
+func (f *Finder) DatastoreListSummary(ctx context.Context, paths ...string) ([]DatastoreSummary, error) {
+	dss, err := f.datastoreList(ctx, []string{"summary"}, nil, paths...)
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := make([]DatastoreSummary, len(dss))
+	for i, ds := range dss {
+		summaries[i] = DatastoreSummary{ds.ds, ds.mo.Summary}
+	}
+
+	return summaries, nil
+}
+
+// DatastoreListShared is like DatastoreList, but includes only datastores that can be accessed
+// by multiple hosts, excluding host-local storage.
+func (f *Finder) DatastoreListShared(ctx context.Context, paths ...string) ([]*object.Datastore, error) {
+	match := func(ds mo.Datastore) bool {
+		return DatastoreSummary{Summary: ds.Summary}.Shared()
+	}
+
+	dss, err := f.datastoreList(ctx, []string{"summary.multipleHostAccess"}, match, paths...)
+	if err != nil {
+		return nil, err
+	}
+
+	return datastores(dss), nil
+}
+
+// DatastoreFreeSpace pairs a Datastore with its free space, as returned by DatastoreListWithFreeSpace.
+type DatastoreFreeSpace struct {
+	Datastore *object.Datastore
+	FreeSpace int64 // In bytes
+}
+
+// DatastoreListWithFreeSpace is like DatastoreList, but includes only datastores with at least minFree
+// bytes of free space.  The free space is retrieved along with the datastores in a single property
+// collector request.
+func (f *Finder) DatastoreListWithFreeSpace(ctx context.Context, minFree int64, paths ...string) ([]DatastoreFreeSpace, error) {
+	match := func(ds mo.Datastore) bool {
+		return ds.Summary.FreeSpace >= minFree
+	}
+
+	dss, err := f.datastoreList(ctx, []string{"summary.freeSpace"}, match, paths...)
+	if err != nil {
+		return nil, err
+	}
+
+	free := make([]DatastoreFreeSpace, len(dss))
+	for i, ds := range dss {
+		free[i] = DatastoreFreeSpace{ds.ds, ds.mo.Summary.FreeSpace}
+	}
+
+	return free, nil
+}
+
+// DatastoreByURL returns the datastore with the given URL, such as "ds:///vmfs/volumes/<uuid>/",
+// searching the datastore folder along with any child folders and datastore clusters.
+func (f *Finder) DatastoreByURL(ctx context.Context, url string) (*object.Datastore, error) {
+	es, err := f.findAll(ctx, f.datastoreFolder)
+	if err != nil {
+		return nil, err
+	}
+
+	var refs []types.ManagedObjectReference
+	elements := make(map[types.ManagedObjectReference]list.Element)
+
+	for _, e := range es {
+		ref := e.Object.Reference()
+		if ref.Type == "Datastore" {
+			refs = append(refs, ref)
+			elements[ref] = e
+		}
+	}
+
+	if len(refs) != 0 {
+		var dss []mo.Datastore
+
+		err = f.retrieve(ctx, refs, []string{"summary.url"}, &dss)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, ds := range dss {
+			if strings.TrimSuffix(ds.Summary.Url, "/") == strings.TrimSuffix(url, "/") {
+				return f.newDatastore(ctx, elements[ds.Self])
+			}
+		}
+	}
+
+	return nil, &NotFoundError{"datastore", url}
+}
+
+// DatastoreInfo pairs a Datastore with its backing type, as returned by DatastoreListWithInfo.
+type DatastoreInfo struct {
+	Datastore *object.Datastore
+	Type      types.HostFileSystemVolumeFileSystemType
+	// VmfsVersion is the VMFS version, such as "5.81", for VMFS datastores only.
+	VmfsVersion string
+	// MaintenanceMode is the maintenance mode state of the datastore, "normal" unless
+	// Storage DRS is evacuating it or has finished doing so.
+	MaintenanceMode types.DatastoreSummaryMaintenanceModeState
+}
+
+// DatastoreListWithInfo is like DatastoreList, but also returns the backing type of each datastore, such as
+// NFS, VMFS or vSAN, along with the VMFS version where applicable and the maintenance mode state.  These are
+// retrieved along with the datastores in a single property collector request.
+func (f *Finder) DatastoreListWithInfo(ctx context.Context, paths ...string) ([]DatastoreInfo, error) {
+	dss, err := f.datastoreList(ctx, []string{"summary.type", "summary.maintenanceMode", "info"}, nil, paths...)
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]DatastoreInfo, len(dss))
+	for i, ds := range dss {
+		infos[i] = DatastoreInfo{
+			Datastore:       ds.ds,
+			Type:            types.HostFileSystemVolumeFileSystemType(ds.mo.Summary.Type),
+			MaintenanceMode: maintenanceMode(ds.mo),
+		}
+
+		if info, ok := ds.mo.Info.(*types.VmfsDatastoreInfo); ok && info.Vmfs != nil {
+			infos[i].VmfsVersion = info.Vmfs.Version
+		}
+	}
+
+	return infos, nil
+}
+
+// maintenanceMode returns the summary.maintenanceMode of ds, which is unset on hosts
+// that do not support datastore maintenance mode.
+func maintenanceMode(ds mo.Datastore) types.DatastoreSummaryMaintenanceModeState {
+	if ds.Summary.MaintenanceMode == "" {
+		return types.DatastoreSummaryMaintenanceModeStateNormal
+	}
+	return types.DatastoreSummaryMaintenanceModeState(ds.Summary.MaintenanceMode)
+}
+
+// DatastoreListAvailable is like DatastoreList, but excludes datastores in or entering maintenance mode.
+// The maintenance mode state is retrieved along with the datastores in a single property collector request.
+func (f *Finder) DatastoreListAvailable(ctx context.Context, paths ...string) ([]*object.Datastore, error) {
+	match := func(ds mo.Datastore) bool {
+		return maintenanceMode(ds) == types.DatastoreSummaryMaintenanceModeStateNormal
+	}
+
+	dss, err := f.datastoreList(ctx, []string{"summary.maintenanceMode"}, match, paths...)
+	if err != nil {
+		return nil, err
+	}
+
+	return datastores(dss), nil
+}
+
+// DatastoreListSortedByFreeSpace is like DatastoreListWithFreeSpace, but includes all datastores,
+// sorted by free space in descending order.  Datastores with the same free space are sorted by name.
+func (f *Finder) DatastoreListSortedByFreeSpace(ctx context.Context, paths ...string) ([]DatastoreFreeSpace, error) {
+	free, err := f.DatastoreListWithFreeSpace(ctx, 0, paths...)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Sort(byFreeSpace(free))
+
+	return free, nil
+}
+
+type byFreeSpace []DatastoreFreeSpace
+
+func (s byFreeSpace) Len() int      { return len(s) }
+func (s byFreeSpace) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s byFreeSpace) Less(i, j int) bool {
+	if s[i].FreeSpace != s[j].FreeSpace {
+		return s[i].FreeSpace > s[j].FreeSpace
+	}
+	return s[i].Datastore.Name() < s[j].Datastore.Name()
+}
+
+func (f *Finder) Datastore(ctx context.Context, path string) (*object.Datastore, error) {
+	dss, err := f.DatastoreList(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(dss) > 1 && !f.firstMatch {
+		return nil, &MultipleFoundError{"datastore", path}
+	}
+
+	return dss[0], nil
+}
+
+func (f *Finder) DefaultDatastore(ctx context.Context) (*object.Datastore, error) {
+	ds, err := f.resolver.DefaultDatastore(ctx, f)
+	if err != nil {
+		return nil, toDefaultError(err)
+	}
+
+	return ds, nil
+}
+
+func (f *Finder) DatastoreOrDefault(ctx context.Context, path string) (*object.Datastore, error) {
+	if path != "" {
+		ds, err := f.Datastore(ctx, path)
+		if err != nil {
+			return nil, err
+		}
+		return ds, nil
+	}
+
+	return f.DefaultDatastore(ctx)
+}
+
+func (f *Finder) DatastoreClusterList(ctx context.Context, paths ...string) ([]*object.StoragePod, error) {
+	es, err := f.findPaths(ctx, f.datastoreFolder, false, nil, paths)
+	if err != nil {
+		return nil, err
+	}
+
+	var sps []*object.StoragePod
+	for _, e := range es {
+		ref := e.Object.Reference()
+		if ref.Type == "StoragePod" {
+			sp := object.NewStoragePod(f.client, ref)
+			sp.InventoryPath = e.Path
+			sps = append(sps, sp)
+		}
+	}
+
+	if len(sps) == 0 {
+		return nil, &NotFoundError{"datastore cluster", joinPaths(paths)}
+	}
+
+	return sps, nil
+}
+
+func (f *Finder) DatastoreCluster(ctx context.Context, path string) (*object.StoragePod, error) {
+	sps, err := f.DatastoreClusterList(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sps) > 1 && !f.firstMatch {
+		return nil, &MultipleFoundError{"datastore cluster", path}
+	}
+
+	return sps[0], nil
+}
+
+func (f *Finder) DefaultDatastoreCluster(ctx context.Context) (*object.StoragePod, error) {
+	sp, err := f.resolver.DefaultDatastoreCluster(ctx, f)
+	if err != nil {
+		return nil, toDefaultError(err)
+	}
+
+	return sp, nil
+}
+
+func (f *Finder) DatastoreClusterOrDefault(ctx context.Context, path string) (*object.StoragePod, error) {
+	if path != "" {
+		sp, err := f.DatastoreCluster(ctx, path)
+		if err != nil {
+			return nil, err
+		}
+		return sp, nil
+	}
+
+	return f.DefaultDatastoreCluster(ctx)
+}
+
+// DatastoreClusterRecommendation asks Storage DRS to place the VM described by spec within the given
+// StoragePod, returning the Datastore of the top recommendation.  The spec PodSelectionSpec.StoragePod
+// field defaults to the given pod if not set.
+func (f *Finder) DatastoreClusterRecommendation(ctx context.Context, pod *object.StoragePod, spec types.StoragePlacementSpec) (*object.Datastore, error) {
+	if spec.PodSelectionSpec.StoragePod == nil {
+		ref := pod.Reference()
+		spec.PodSelectionSpec.StoragePod = &ref
+	}
+
+	srm := object.NewStorageResourceManager(f.client)
+
+	result, err := srm.RecommendDatastores(ctx, spec)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, rec := range result.Recommendations {
+		for _, action := range rec.Action {
+			if sp, ok := action.(*types.StoragePlacementAction); ok {
+				r, err := f.ObjectReference(ctx, sp.Destination)
+				if err != nil {
+					return nil, err
+				}
+
+				return r.(*object.Datastore), nil
+			}
+		}
+	}
+
+	return nil, &NotFoundError{"datastore recommendation", pod.InventoryPath}
+}
+
+func (f *Finder) ComputeResourceList(ctx context.Context, paths ...string) ([]*object.ComputeResource, error) {
+	es, err := f.findPaths(ctx, f.hostFolder, false, nil, paths)
+	if err != nil {
+		return nil, err
+	}
+
+	var crs []*object.ComputeResource
+	for _, e := range es {
+		var cr *object.ComputeResource
+
+		switch o := e.Object.(type) {
+		case mo.ComputeResource, mo.ClusterComputeResource:
+			cr = object.NewComputeResource(f.client, o.Reference())
+		default:
+			continue
+		}
+
+		cr.InventoryPath = e.Path
+		crs = append(crs, cr)
+	}
+
+	if len(crs) == 0 {
+		return nil, &NotFoundError{"compute resource", joinPaths(paths)}
+	}
+
+	return crs, nil
+}
+
+func (f *Finder) ComputeResource(ctx context.Context, path string) (*object.ComputeResource, error) {
+	crs, err := f.ComputeResourceList(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(crs) > 1 && !f.firstMatch {
+		return nil, &MultipleFoundError{"compute resource", path}
+	}
+
+	return crs[0], nil
+}
+
+func (f *Finder) DefaultComputeResource(ctx context.Context) (*object.ComputeResource, error) {
+	cr, err := f.resolver.DefaultComputeResource(ctx, f)
+	if err != nil {
+		return nil, toDefaultError(err)
+	}
+
+	return cr, nil
+}
+
+func (f *Finder) ComputeResourceOrDefault(ctx context.Context, path string) (*object.ComputeResource, error) {
+	if path != "" {
+		cr, err := f.ComputeResource(ctx, path)
+		if err != nil {
+			return nil, err
+		}
+		return cr, nil
+	}
+
+	return f.DefaultComputeResource(ctx)
+}
+
+func (f *Finder) ClusterComputeResourceList(ctx context.Context, paths ...string) ([]*object.ClusterComputeResource, error) {
+	es, err := f.findPaths(ctx, f.hostFolder, false, nil, paths)
+	if err != nil {
+		return nil, err
+	}
+
+	var ccrs []*object.ClusterComputeResource
+	for _, e := range es {
+		var ccr *object.ClusterComputeResource
+
+		switch o := e.Object.(type) {
+		case mo.ClusterComputeResource:
+			ccr = object.NewClusterComputeResource(f.client, o.Reference())
+		default:
+			continue
+		}
+
+		ccr.InventoryPath = e.Path
+		ccrs = append(ccrs, ccr)
+	}
+
+	if len(ccrs) == 0 {
+		return nil, &NotFoundError{"cluster", joinPaths(paths)}
+	}
+
+	return ccrs, nil
+}
+
+// ClusterVsan pairs a ClusterComputeResource with whether vSAN is enabled, as returned by ClusterComputeResourceListWithVsan.
+type ClusterVsan struct {
+	Cluster     *object.ClusterComputeResource
+	VsanEnabled bool
+}
+
+// ClusterComputeResourceListWithVsan is like ClusterComputeResourceList, but also returns whether vSAN is enabled
+// for each cluster, from configurationEx.vsanConfigInfo.enabled retrieved in the same property collector request
+// used for traversal.
+func (f *Finder) ClusterComputeResourceListWithVsan(ctx context.Context, paths ...string) ([]ClusterVsan, error) {
+	kinds := map[string][]string{
+		"ClusterComputeResource": {"configurationEx"},
+	}
+
+	es, err := f.findPaths(ctx, f.hostFolder, false, kinds, paths)
+	if err != nil {
+		return nil, err
+	}
+
+	var ccrs []ClusterVsan
+	for _, e := range es {
+		switch o := e.Object.(type) {
+		case mo.ClusterComputeResource:
+			ccr := object.NewClusterComputeResource(f.client, o.Reference())
+			ccr.InventoryPath = e.Path
+
+			c := ClusterVsan{Cluster: ccr}
+			if info, ok := o.ConfigurationEx.(*types.ClusterConfigInfoEx); ok && info.VsanConfigInfo != nil {
+				c.VsanEnabled = info.VsanConfigInfo.Enabled != nil && *info.VsanConfigInfo.Enabled
+			}
+
+			ccrs = append(ccrs, c)
+		}
+	}
+
+	if len(ccrs) == 0 {
+		return nil, &NotFoundError{"cluster", joinPaths(paths)}
+	}
+
+	return ccrs, nil
+}
+
+func (f *Finder) ClusterComputeResource(ctx context.Context, path string) (*object.ClusterComputeResource, error) {
+	ccrs, err := f.ClusterComputeResourceList(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ccrs) > 1 && !f.firstMatch {
+		return nil, &MultipleFoundError{"cluster", path}
+	}
+
+	return ccrs[0], nil
+}
+
+func (f *Finder) HostSystemList(ctx context.Context, paths ...string) ([]*object.HostSystem, error) {
+	kinds := map[string][]string{
+		"ComputeResource":        {"host"},
+		"ClusterComputeResource": {"host"},
+	}
+
+	es, err := f.findPaths(ctx, f.hostFolder, false, kinds, paths)
+	if err != nil {
+		return nil, err
+	}
+
+	crHosts, err := f.computeResourceHosts(ctx, es)
+	if err != nil {
+		return nil, err
+	}
+
+	var hss []*object.HostSystem
+	seen := make(map[types.ManagedObjectReference]bool)
+
+	for _, e := range es {
+		var hs *object.HostSystem
+
+		switch o := e.Object.(type) {
+		case mo.HostSystem:
+			if seen[o.Reference()] {
+				continue
+			}
+			seen[o.Reference()] = true
+
+			hs = object.NewHostSystem(f.client, o.Reference())
+
+			hs.InventoryPath = e.Path
+			hss = append(hss, hs)
+		case mo.ComputeResource, mo.ClusterComputeResource:
+			for _, host := range crHosts[o.Reference()] {
+				if seen[host.Reference()] {
+					continue
+				}
+				seen[host.Reference()] = true
+				hss = append(hss, host)
+			}
+		}
+	}
+
+	if len(hss) == 0 {
+		return nil, &NotFoundError{"host", joinPaths(paths)}
+	}
+
+	return hss, nil
+}
+
+// computeResourceHosts returns the hosts of each compute resource in es, whose "host" property must have
+// been retrieved, keyed by compute resource.  The name and parent of all hosts are retrieved in a single
+// request.  Only hosts whose parent is the compute resource are returned, such that a host listed by the
+// compute resource but since moved elsewhere (for example while being added to or removed from a cluster)
+// is not included.
+func (f *Finder) computeResourceHosts(ctx context.Context, es []list.Element) (map[types.ManagedObjectReference][]*object.HostSystem, error) {
+	crs := make(map[types.ManagedObjectReference][]types.ManagedObjectReference)
+	var refs []types.ManagedObjectReference
+
+	for _, e := range es {
+		var hosts []types.ManagedObjectReference
+
+		switch o := e.Object.(type) {
+		case mo.ComputeResource:
+			hosts = o.Host
+		case mo.ClusterComputeResource:
+			hosts = o.Host
+		default:
+			continue
+		}
+
+		crs[e.Object.Reference()] = hosts
+		refs = append(refs, hosts...)
+	}
+
+	result := make(map[types.ManagedObjectReference][]*object.HostSystem)
+
+	if len(refs) == 0 {
+		return result, nil
+	}
+
+	var hs []mo.HostSystem
+
+	err := f.retrieve(ctx, refs, []string{"name", "parent"}, &hs)
+	if err != nil {
+		return nil, err
+	}
+
+	byRef := make(map[types.ManagedObjectReference]mo.HostSystem, len(hs))
+	for _, h := range hs {
+		byRef[h.Reference()] = h
+	}
+
+	for _, e := range es {
+		ref := e.Object.Reference()
+
+		for _, host := range crs[ref] {
+			h, ok := byRef[host]
+			if !ok || h.Parent == nil || *h.Parent != ref {
+				continue
+			}
+
+			o := object.NewHostSystem(f.client, h.Reference())
+			o.InventoryPath = path.Join(e.Path, h.Name)
+			result[ref] = append(result[ref], o)
+		}
+	}
+
+	return result, nil
+}
+
+// hostSystemList finds the hosts matching any of the given paths, as HostSystemList does, and retrieves
+// the given properties for all of them in a single property collector request.
+func (f *Finder) hostSystemList(ctx context.Context, props []string, paths ...string) ([]*object.HostSystem, []mo.HostSystem, error) {
+	if len(paths) == 0 {
+		paths = []string{"*"}
+	}
+
+	var hss []*object.HostSystem
+	var refs []types.ManagedObjectReference
+	seen := make(map[types.ManagedObjectReference]bool)
+
+	for _, p := range paths {
+		hosts, err := f.HostSystemList(ctx, p)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for _, host := range hosts {
+			ref := host.Reference()
+			if seen[ref] {
+				continue
+			}
+			seen[ref] = true
+
+			hss = append(hss, host)
+			refs = append(refs, ref)
+		}
+	}
+
+	var hosts []mo.HostSystem
+
+	err := f.retrieve(ctx, refs, props, &hosts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return hss, hosts, nil
+}
+
+// HostSystemState pairs a HostSystem with its runtime state, as returned by HostSystemListWithState.
+type HostSystemState struct {
+	Host              *object.HostSystem
+	ConnectionState   types.HostSystemConnectionState
+	InMaintenanceMode bool
+}
+
+// HostSystemListWithState is like HostSystemList, but also returns the connection and maintenance mode
+// state of each host, retrieved for all hosts in a single property collector request.
+func (f *Finder) HostSystemListWithState(ctx context.Context, paths ...string) ([]HostSystemState, error) {
+	hss, hosts, err := f.hostSystemList(ctx, []string{"runtime.connectionState", "runtime.inMaintenanceMode"}, paths...)
+	if err != nil {
+		return nil, err
+	}
+
+	runtime := make(map[types.ManagedObjectReference]types.HostRuntimeInfo, len(hosts))
+	for _, host := range hosts {
+		runtime[host.Self] = host.Runtime
+	}
+
+	states := make([]HostSystemState, 0, len(hss))
+	for _, hs := range hss {
+		info := runtime[hs.Reference()]
+
+		states = append(states, HostSystemState{
+			Host:              hs,
+			ConnectionState:   info.ConnectionState,
+			InMaintenanceMode: info.InMaintenanceMode,
+		})
+	}
+
+	return states, nil
+}
+
+// HostSystemHardware pairs a HostSystem with its hardware summary, as returned by HostSystemListWithHardware.
+// The summary includes the CPU model, number of CPU cores and memory size.
+type HostSystemHardware struct {
+	Host     *object.HostSystem
+	Hardware *types.HostHardwareSummary
+}
+
+// HostSystemListWithHardware is like HostSystemList, but also returns the summary.hardware property
+// of each host, retrieved for all hosts in a single property collector request.
+func (f *Finder) HostSystemListWithHardware(ctx context.Context, paths ...string) ([]HostSystemHardware, error) {
+	hss, hosts, err := f.hostSystemList(ctx, []string{"summary.hardware"}, paths...)
+	if err != nil {
+		return nil, err
+	}
+
+	hardware := make(map[types.ManagedObjectReference]*types.HostHardwareSummary, len(hosts))
+	for _, host := range hosts {
+		hardware[host.Self] = host.Summary.Hardware
+	}
+
+	hws := make([]HostSystemHardware, 0, len(hss))
+	for _, hs := range hss {
+		hws = append(hws, HostSystemHardware{hs, hardware[hs.Reference()]})
+	}
+
+	return hws, nil
+}
+
+// HostVMotionGroup is a set of hosts with a vMotion enabled virtual NIC on the same subnet,
+// as returned by HostSystemVMotionGroups.  Subnet is in CIDR notation, such as "10.0.0.0/24".
+type HostVMotionGroup struct {
+	Subnet string
+	Hosts  []*object.HostSystem
+}
+
+// HostSystemVMotionGroups groups the given hosts by the subnet of their vMotion enabled virtual NICs,
+// such that hosts in the same group can reach each other over a vMotion network.  The vNic config of
+// all hosts is retrieved in a single property collector request.  A host with vMotion NICs on more than
+// one subnet is included in each of the groups; hosts without a vMotion NIC are not included in any group.
+// Groups are sorted by subnet and the hosts of each group are in the given order.
+func (f *Finder) HostSystemVMotionGroups(ctx context.Context, hosts []*object.HostSystem) ([]HostVMotionGroup, error) {
+	if len(hosts) == 0 {
+		return nil, nil
+	}
+
+	refs := make([]types.ManagedObjectReference, 0, len(hosts))
+	for _, host := range hosts {
+		refs = append(refs, host.Reference())
+	}
+
+	var mhs []mo.HostSystem
+
+	err := f.retrieve(ctx, refs, []string{"config.virtualNicManagerInfo.netConfig"}, &mhs)
+	if err != nil {
+		return nil, err
+	}
+
+	subnets := make(map[types.ManagedObjectReference][]string, len(mhs))
+	for _, mh := range mhs {
+		if mh.Config != nil {
+			subnets[mh.Self] = vmotionSubnets(mh.Config.VirtualNicManagerInfo)
+		}
+	}
+
+	var groups []HostVMotionGroup
+	index := make(map[string]int)
+
+	for _, host := range hosts {
+		for _, subnet := range subnets[host.Reference()] {
+			i, ok := index[subnet]
+			if !ok {
+				i = len(groups)
+				index[subnet] = i
+				groups = append(groups, HostVMotionGroup{Subnet: subnet})
+			}
+
+			groups[i].Hosts = append(groups[i].Hosts, host)
+		}
+	}
+
+	sort.Sort(bySubnet(groups))
+
+	return groups, nil
+}
+
+type bySubnet []HostVMotionGroup
+
+func (l bySubnet) Len() int           { return len(l) }
+func (l bySubnet) Swap(i, j int)      { l[i], l[j] = l[j], l[i] }
+func (l bySubnet) Less(i, j int) bool { return l[i].Subnet < l[j].Subnet }
+
+// vmotionSubnets returns the distinct subnets of the selected vMotion virtual NICs in the given config.
+// IPv6 link-local addresses are ignored, as they cannot be used to reach other hosts.
+func vmotionSubnets(info *types.HostVirtualNicManagerInfo) []string {
+	if info == nil {
+		return nil
+	}
+
+	var subnets []string
+	seen := make(map[string]bool)
+
+	add := func(ip net.IP, mask net.IPMask) {
+		n := net.IPNet{IP: ip.Mask(mask), Mask: mask}
+		if s := n.String(); !seen[s] {
+			seen[s] = true
+			subnets = append(subnets, s)
+		}
+	}
+
+	for _, nc := range info.NetConfig {
+		if nc.NicType != string(types.HostVirtualNicManagerNicTypeVmotion) {
+			continue
+		}
+
+		for _, key := range nc.SelectedVnic {
+			for _, vnic := range nc.CandidateVnic {
+				if vnic.Key != key || vnic.Spec.Ip == nil {
+					continue
+				}
+
+				ip := vnic.Spec.Ip
+
+				addr := net.ParseIP(ip.IpAddress).To4()
+				mask := net.ParseIP(ip.SubnetMask).To4()
+				if addr != nil && mask != nil {
+					add(addr, net.IPMask(mask))
+				}
+
+				if ip.IpV6Config == nil {
+					continue
+				}
+
+				for _, v6 := range ip.IpV6Config.IpV6Address {
+					addr := net.ParseIP(v6.IpAddress)
+					if addr == nil || addr.IsLinkLocalUnicast() {
+						continue
+					}
+					add(addr, net.CIDRMask(int(v6.PrefixLength), 128))
+				}
+			}
+		}
+	}
+
+	return subnets
+}
+
+func (f *Finder) HostSystem(ctx context.Context, path string) (*object.HostSystem, error) {
+	hss, err := f.HostSystemList(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(hss) > 1 && !f.firstMatch {
+		return nil, &MultipleFoundError{"host", path}
+	}
+
+	return hss[0], nil
+}
+
+// HostSystemByName returns the host with the given name, searching every compute resource
+// below the datacenter's host folder, including those in nested folders.
+func (f *Finder) HostSystemByName(ctx context.Context, name string) (*object.HostSystem, error) {
+	es, err := f.findAll(ctx, f.hostFolder)
+	if err != nil {
+		return nil, err
+	}
+
+	// Retrieve requires references of the same type, group compute resources by type
+	refs := make(map[string][]types.ManagedObjectReference)
+	paths := make(map[types.ManagedObjectReference]string)
+
+	for _, e := range es {
+		ref := e.Object.Reference()
+		switch ref.Type {
+		case "ComputeResource", "ClusterComputeResource":
+			refs[ref.Type] = append(refs[ref.Type], ref)
+			paths[ref] = e.Path
+		}
+	}
+
+	var hosts []types.ManagedObjectReference
+	parents := make(map[types.ManagedObjectReference]string)
+
+	for _, crefs := range refs {
+		var crs []mo.ComputeResource
+
+		err = f.retrieve(ctx, crefs, []string{"host"}, &crs)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, cr := range crs {
+			for _, host := range cr.Host {
+				if _, ok := parents[host]; !ok {
+					hosts = append(hosts, host)
+					parents[host] = paths[cr.Self]
+				}
+			}
+		}
+	}
+
+	var hss []*object.HostSystem
+
+	if len(hosts) != 0 {
+		var mhs []mo.HostSystem
+
+		err = f.retrieve(ctx, hosts, []string{"name"}, &mhs)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, mh := range mhs {
+			if mh.Name == name {
+				hs := object.NewHostSystem(f.client, mh.Self)
+				hs.InventoryPath = path.Join(parents[mh.Self], mh.Name)
+				hss = append(hss, hs)
+			}
+		}
+	}
+
+	if len(hss) == 0 {
+		return nil, &NotFoundError{"host", name}
+	}
+
+	if len(hss) > 1 && !f.firstMatch {
+		return nil, &MultipleFoundError{"host", name}
+	}
+
+	// Stable ordering such that the first match is the same across calls
+	first := hss[0]
+	for _, hs := range hss[1:] {
+		if hs.InventoryPath < first.InventoryPath {
+			first = hs
+		}
+	}
+
+	return first, nil
+}
+
+func (f *Finder) DefaultHostSystem(ctx context.Context) (*object.HostSystem, error) {
+	hs, err := f.resolver.DefaultHostSystem(ctx, f)
+	if err != nil {
+		return nil, toDefaultError(err)
+	}
+
+	return hs, nil
+}
+
+func (f *Finder) HostSystemOrDefault(ctx context.Context, path string) (*object.HostSystem, error) {
+	if path != "" {
+		hs, err := f.HostSystem(ctx, path)
+		if err != nil {
+			return nil, err
+		}
+		return hs, nil
+	}
+
+	return f.DefaultHostSystem(ctx)
+}
+
+func (f *Finder) NetworkList(ctx context.Context, paths ...string) ([]object.NetworkReference, error) {
+	kinds := f.networkBackingProperties()
+	if f.excludeUplinks {
+		kinds["DistributedVirtualPortgroup"] = append(kinds["DistributedVirtualPortgroup"], "config.uplink")
+	}
+
+	es, err := f.findPaths(ctx, f.networkFolder, false, kinds, paths)
+	if err != nil {
+		return nil, err
+	}
+
+	var ns []object.NetworkReference
+	backings := networkBackings(es)
+
+	for _, e := range es {
+		ref := e.Object.Reference()
+		switch ref.Type {
+		case "Network", "OpaqueNetwork":
+			r := object.NewNetwork(f.client, ref)
+			r.InventoryPath = e.Path
+			ns = append(ns, r)
+		case "DistributedVirtualPortgroup":
+			if o, ok := e.Object.(mo.DistributedVirtualPortgroup); ok && f.excludeUplinks && o.Config.Uplink != nil && *o.Config.Uplink {
+				continue
+			}
+
+			r := object.NewDistributedVirtualPortgroup(f.client, ref)
+			r.InventoryPath = e.Path
+			ns = append(ns, r)
+		case "DistributedVirtualSwitch", "VmwareDistributedVirtualSwitch":
+			r := object.NewDistributedVirtualSwitch(f.client, ref)
+			r.InventoryPath = e.Path
+			ns = append(ns, r)
+		}
+	}
+
+	if f.collapseNetworks {
+		ns = collapseNetworks(ns, backings)
+	}
+
+	if len(ns) == 0 {
+		return nil, &NotFoundError{"network", joinPaths(paths)}
+	}
+
+	return ns, nil
+}
+
+// NetworkVLAN pairs a network with its VLAN configuration, as returned by NetworkListWithVLAN.
+// VLAN is nil for networks that are not a DistributedVirtualPortgroup.
+type NetworkVLAN struct {
+	Network object.NetworkReference
+	VLAN    types.BaseVmwareDistributedVirtualSwitchVlanSpec
+}
+
+// NetworkListWithVLAN is like NetworkList, but also returns the default VLAN configuration of each
+// DistributedVirtualPortgroup, retrieved in the same property collector request used for traversal.
+// DistributedVirtualSwitch objects are not included.
+func (f *Finder) NetworkListWithVLAN(ctx context.Context, paths ...string) ([]NetworkVLAN, error) {
+	kinds := f.networkBackingProperties()
+	kinds["DistributedVirtualPortgroup"] = append(kinds["DistributedVirtualPortgroup"], "config.defaultPortConfig")
+
+	es, err := f.findPaths(ctx, f.networkFolder, false, kinds, paths)
+	if err != nil {
+		return nil, err
+	}
+
+	var ns []NetworkVLAN
+	for _, e := range es {
+		ref := e.Object.Reference()
+		switch ref.Type {
+		case "Network", "OpaqueNetwork":
+			r := object.NewNetwork(f.client, ref)
+			r.InventoryPath = e.Path
+			ns = append(ns, NetworkVLAN{Network: r})
+		case "DistributedVirtualPortgroup":
+			r := object.NewDistributedVirtualPortgroup(f.client, ref)
+			r.InventoryPath = e.Path
+			n := NetworkVLAN{Network: r}
+
+			if o, ok := e.Object.(mo.DistributedVirtualPortgroup); ok {
+				if s, ok := o.Config.DefaultPortConfig.(*types.VMwareDVSPortSetting); ok {
+					n.VLAN = s.Vlan
+				}
+			}
+
+			ns = append(ns, n)
+		}
+	}
+
+	if f.collapseNetworks {
+		refs := make([]object.NetworkReference, len(ns))
+		for i := range ns {
+			refs[i] = ns[i].Network
+		}
+
+		keep := make(map[types.ManagedObjectReference]bool)
+		for _, r := range collapseNetworks(refs, networkBackings(es)) {
+			keep[r.Reference()] = true
+		}
+
+		var out []NetworkVLAN
+		for _, n := range ns {
+			if keep[n.Network.Reference()] {
+				out = append(out, n)
+			}
+		}
+		ns = out
+	}
+
+	if len(ns) == 0 {
+		return nil, &NotFoundError{"network", joinPaths(paths)}
+	}
+
+	return ns, nil
+}
+
+// PortgroupUsage pairs a DistributedVirtualPortgroup with its number of ports and attached VMs,
+// as returned by PortgroupListWithUsage.
+type PortgroupUsage struct {
+	Portgroup *object.DistributedVirtualPortgroup
+	NumPorts  int32
+	NumVMs    int
+}
+
+// PortgroupListWithUsage returns the DistributedVirtualPortgroups matching the given path, along with their
+// config.numPorts and the number of VMs in their vm property, retrieved in the same property collector
+// request used for traversal.
+func (f *Finder) PortgroupListWithUsage(ctx context.Context, paths ...string) ([]PortgroupUsage, error) {
+	kinds := map[string][]string{
+		"DistributedVirtualPortgroup": {"config.numPorts", "vm"},
+	}
+
+	es, err := f.findPaths(ctx, f.networkFolder, false, kinds, paths)
+	if err != nil {
+		return nil, err
+	}
+
+	var pgs []PortgroupUsage
+	for _, e := range es {
+		switch o := e.Object.(type) {
+		case mo.DistributedVirtualPortgroup:
+			pg := object.NewDistributedVirtualPortgroup(f.client, o.Reference())
+			pg.InventoryPath = e.Path
+			pgs = append(pgs, PortgroupUsage{pg, o.Config.NumPorts, len(o.Vm)})
+		}
+	}
+
+	if len(pgs) == 0 {
+		return nil, &NotFoundError{"portgroup", joinPaths(paths)}
+	}
+
+	return pgs, nil
+}
+
+// PortgroupBinding pairs a DistributedVirtualPortgroup with its config.type, as returned by PortgroupListWithBinding.
+type PortgroupBinding struct {
+	Portgroup *object.DistributedVirtualPortgroup
+	Binding   types.DistributedVirtualPortgroupPortgroupType
+}
+
+// PortgroupListWithBinding returns the DistributedVirtualPortgroups matching the given paths, along with their
+// port binding type (earlyBinding, lateBinding or ephemeral), retrieved in the same property collector
+// request used for traversal.
+func (f *Finder) PortgroupListWithBinding(ctx context.Context, paths ...string) ([]PortgroupBinding, error) {
+	kinds := map[string][]string{
+		"DistributedVirtualPortgroup": {"config.type"},
+	}
+
+	es, err := f.findPaths(ctx, f.networkFolder, false, kinds, paths)
+	if err != nil {
+		return nil, err
+	}
+
+	var pgs []PortgroupBinding
+	for _, e := range es {
+		switch o := e.Object.(type) {
+		case mo.DistributedVirtualPortgroup:
+			pg := object.NewDistributedVirtualPortgroup(f.client, o.Reference())
+			pg.InventoryPath = e.Path
+			pgs = append(pgs, PortgroupBinding{pg, types.DistributedVirtualPortgroupPortgroupType(o.Config.Type)})
+		}
+	}
+
+	if len(pgs) == 0 {
+		return nil, &NotFoundError{"portgroup", joinPaths(paths)}
+	}
+
+	return pgs, nil
+}
+
+// NetworkListByBinding returns the DistributedVirtualPortgroups matching the given paths
+// with the given port binding type, such as "ephemeral".
+func (f *Finder) NetworkListByBinding(ctx context.Context, binding string, paths ...string) ([]*object.DistributedVirtualPortgroup, error) {
+	switch types.DistributedVirtualPortgroupPortgroupType(binding) {
+	case types.DistributedVirtualPortgroupPortgroupTypeEarlyBinding,
+		types.DistributedVirtualPortgroupPortgroupTypeLateBinding,
+		types.DistributedVirtualPortgroupPortgroupTypeEphemeral:
+	default:
+		return nil, fmt.Errorf("invalid port binding type: %q", binding)
+	}
+
+	pgs, err := f.PortgroupListWithBinding(ctx, paths...)
+	if err != nil {
+		return nil, err
+	}
+
+	var ns []*object.DistributedVirtualPortgroup
+	for _, pg := range pgs {
+		if string(pg.Binding) == binding {
+			ns = append(ns, pg.Portgroup)
+		}
+	}
+
+	if len(ns) == 0 {
+		return nil, &NotFoundError{binding + " portgroup", joinPaths(paths)}
+	}
+
+	return ns, nil
+}
+
+// networkBackingProperties returns the properties collapseNetworks compares, keyed by type,
+// if collapsing networks is enabled.
+func (f *Finder) networkBackingProperties() map[string][]string {
+	kinds := make(map[string][]string)
+
+	if f.collapseNetworks {
+		for _, kind := range []string{"Network", "DistributedVirtualPortgroup"} {
+			kinds[kind] = []string{"summary", "host"}
+		}
+	}
+
+	return kinds
+}
+
+// networkBackings returns the Network properties of each Network and DistributedVirtualPortgroup in es.
+func networkBackings(es []list.Element) map[types.ManagedObjectReference]mo.Network {
+	backings := make(map[types.ManagedObjectReference]mo.Network)
+
+	for _, e := range es {
+		switch o := e.Object.(type) {
+		case mo.Network:
+			backings[o.Reference()] = o
+		case mo.DistributedVirtualPortgroup:
+			backings[o.Reference()] = o.Network
+		}
+	}
+
+	return backings
+}
+
+// collapseNetworks removes Network entries backed by the same network as a DistributedVirtualPortgroup,
+// such that both backings of the same network are returned as a single DistributedVirtualPortgroup.
+// A Network shares the backing of a portgroup when their summaries name the same network and each host
+// the Network is available on is also a member of the portgroup.  Entries without a backing in backings,
+// such as an OpaqueNetwork, are kept.
+func collapseNetworks(ns []object.NetworkReference, backings map[types.ManagedObjectReference]mo.Network) []object.NetworkReference {
+	pgs := make(map[string][]map[types.ManagedObjectReference]bool)
+
+	for _, n := range ns {
+		b, ok := backings[n.Reference()]
+		if n.Reference().Type != "DistributedVirtualPortgroup" || !ok || b.Summary == nil {
+			continue
+		}
+
+		hosts := make(map[types.ManagedObjectReference]bool)
+		for _, h := range b.Host {
+			hosts[h] = true
+		}
+
+		name := b.Summary.GetNetworkSummary().Name
+		pgs[name] = append(pgs[name], hosts)
+	}
+
+	shared := func(b mo.Network) bool {
+		if b.Summary == nil || len(b.Host) == 0 {
+			return false
+		}
+
+		for _, hosts := range pgs[b.Summary.GetNetworkSummary().Name] {
+			member := true
+			for _, h := range b.Host {
+				member = member && hosts[h]
+			}
+
+			if member {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	var out []object.NetworkReference
+
+	for _, n := range ns {
+		if b, ok := backings[n.Reference()]; ok && n.Reference().Type == "Network" && shared(b) {
+			continue
+		}
+
+		out = append(out, n)
+	}
+
+	return out
+}
+
+// PortgroupSwitch returns the DistributedVirtualSwitch the given portgroup belongs to,
+// with its InventoryPath field set.
+func (f *Finder) PortgroupSwitch(ctx context.Context, pg *object.DistributedVirtualPortgroup) (*object.DistributedVirtualSwitch, error) {
+	var dvp mo.DistributedVirtualPortgroup
+
+	err := f.retrieveOne(ctx, pg.Reference(), []string{"config.distributedVirtualSwitch"}, &dvp)
+	if err != nil {
+		return nil, err
+	}
+
+	if dvp.Config.DistributedVirtualSwitch == nil {
+		return nil, &NotFoundError{"distributed virtual switch", pg.InventoryPath}
+	}
+
+	e, err := f.Element(ctx, *dvp.Config.DistributedVirtualSwitch)
+	if err != nil {
+		return nil, err
+	}
+
+	dvs := object.NewDistributedVirtualSwitch(f.client, *dvp.Config.DistributedVirtualSwitch)
+	dvs.InventoryPath = e.Path
+
+	return dvs, nil
+}
+
+// DistributedVirtualSwitchByUuid returns the DistributedVirtualSwitch with the given UUID,
+// with its InventoryPath field set.
+func (f *Finder) DistributedVirtualSwitchByUuid(ctx context.Context, uuid string) (*object.DistributedVirtualSwitch, error) {
+	m := f.client.ServiceContent.DvSwitchManager
+	if m == nil {
+		return nil, errors.New("distributed virtual switch manager not available")
+	}
+
+	req := types.QueryDvsByUuid{
+		This: *m,
+		Uuid: uuid,
+	}
+
+	res, err := methods.QueryDvsByUuid(ctx, f.client, &req)
+	if err != nil {
+		if soap.IsSoapFault(err) {
+			if _, ok := soap.ToSoapFault(err).VimFault().(types.NotFound); ok {
+				return nil, &NotFoundError{"distributed virtual switch", uuid}
+			}
+		}
+		return nil, err
+	}
+
+	if res.Returnval == nil {
+		return nil, &NotFoundError{"distributed virtual switch", uuid}
+	}
+
+	e, err := f.Element(ctx, *res.Returnval)
+	if err != nil {
+		return nil, err
+	}
+
+	dvs := object.NewDistributedVirtualSwitch(f.client, *res.Returnval)
+	dvs.InventoryPath = e.Path
+
+	return dvs, nil
+}
+
+// NetworkByKey returns the DistributedVirtualPortgroup with the given portgroup key.
+// The key is the value found in the PortgroupKey field of a VM's ethernet card backing,
+// making it possible to resolve an existing NIC backing back to its portgroup.
+func (f *Finder) NetworkByKey(ctx context.Context, key string) (object.NetworkReference, error) {
+	es, err := f.findAll(ctx, f.networkFolder)
+	if err != nil {
+		return nil, err
+	}
+
+	var refs []types.ManagedObjectReference
+	paths := make(map[types.ManagedObjectReference]string)
+
+	for _, e := range es {
+		ref := e.Object.Reference()
+		if ref.Type == "DistributedVirtualPortgroup" {
+			refs = append(refs, ref)
+			paths[ref] = e.Path
+		}
+	}
+
+	if len(refs) != 0 {
+		var pgs []mo.DistributedVirtualPortgroup
+
+		err = f.retrieve(ctx, refs, []string{"key"}, &pgs)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, pg := range pgs {
+			if pg.Key == key {
+				r := object.NewDistributedVirtualPortgroup(f.client, pg.Self)
+				r.InventoryPath = paths[pg.Self]
+				return r, nil
+			}
+		}
+	}
+
+	return nil, &NotFoundError{"network", key}
+}
+
+func (f *Finder) Network(ctx context.Context, path string) (object.NetworkReference, error) {
+	networks, err := f.NetworkList(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(networks) > 1 && !f.firstMatch {
+		return nil, &MultipleFoundError{"network", path}
+	}
+
+	return networks[0], nil
+}
+
+func (f *Finder) DefaultNetwork(ctx context.Context) (object.NetworkReference, error) {
+	network, err := f.resolver.DefaultNetwork(ctx, f)
+	if err != nil {
+		return nil, toDefaultError(err)
+	}
+
+	return network, nil
+}
+
+func (f *Finder) NetworkOrDefault(ctx context.Context, path string) (object.NetworkReference, error) {
+	if path != "" {
+		network, err := f.Network(ctx, path)
+		if err != nil {
+			return nil, err
+		}
+		return network, nil
 	}
 
-	return &e[0], nil
+	return f.DefaultNetwork(ctx)
 }
 
-// ObjectReference converts the given ManagedObjectReference to a type from the object package via object.NewReference
-// with the object.Common.InventoryPath field set.
-func (f *Finder) ObjectReference(ctx context.Context, ref types.ManagedObjectReference) (object.Reference, error) {
-	e, err := f.Element(ctx, ref)
+func (f *Finder) ResourcePoolList(ctx context.Context, paths ...string) ([]*object.ResourcePool, error) {
+	es, err := f.findPaths(ctx, f.hostFolder, true, nil, paths)
 	if err != nil {
 		return nil, err
 	}
 
-	r := object.NewReference(f.client, ref)
+	var rps []*object.ResourcePool
+	for _, e := range es {
+		var rp *object.ResourcePool
 
-	type common interface {
-		SetInventoryPath(string)
+		switch o := e.Object.(type) {
+		case mo.ResourcePool:
+			rp = object.NewResourcePool(f.client, o.Reference())
+			rp.InventoryPath = e.Path
+			rps = append(rps, rp)
+		}
 	}
 
-	r.(common).SetInventoryPath(e.Path)
-
-	if f.dc != nil {
-		if ds, ok := r.(*object.Datastore); ok {
-			ds.DatacenterPath = f.dc.InventoryPath
-		}
+	if len(rps) == 0 {
+		return nil, &NotFoundError{"resource pool", joinPaths(paths)}
 	}
 
-	return r, nil
+	return rps, nil
 }
 
-func (f *Finder) ManagedObjectList(ctx context.Context, path string) ([]list.Element, error) {
-	return f.managedObjectList(ctx, path, false)
+// ResourcePoolUsage pairs a ResourcePool with its runtime CPU and memory usage, as returned by ResourcePoolListWithUsage.
+type ResourcePoolUsage struct {
+	Pool   *object.ResourcePool
+	CPU    types.ResourcePoolResourceUsage
+	Memory types.ResourcePoolResourceUsage
 }
 
-func (f *Finder) ManagedObjectListChildren(ctx context.Context, path string) ([]list.Element, error) {
-	return f.managedObjectList(ctx, path, true)
-}
+// ResourcePoolListWithUsage is like ResourcePoolList, but also returns the runtime CPU and memory reservation
+// and usage of each pool, retrieved in the same property collector request used for traversal.
+func (f *Finder) ResourcePoolListWithUsage(ctx context.Context, paths ...string) ([]ResourcePoolUsage, error) {
+	kinds := map[string][]string{
+		"ResourcePool": {"runtime.cpu", "runtime.memory"},
+	}
 
-func (f *Finder) DatacenterList(ctx context.Context, path string) ([]*object.Datacenter, error) {
-	es, err := f.find(ctx, f.rootFolder, false, path)
+	es, err := f.findPaths(ctx, f.hostFolder, true, kinds, paths)
 	if err != nil {
 		return nil, err
 	}
 
-	var dcs []*object.Datacenter
+	var rps []ResourcePoolUsage
 	for _, e := range es {
-		ref := e.Object.Reference()
-		if ref.Type == "Datacenter" {
-			dc := object.NewDatacenter(f.client, ref)
-			dc.InventoryPath = e.Path
-			dcs = append(dcs, dc)
+		switch o := e.Object.(type) {
+		case mo.ResourcePool:
+			rp := object.NewResourcePool(f.client, o.Reference())
+			rp.InventoryPath = e.Path
+			rps = append(rps, ResourcePoolUsage{rp, o.Runtime.Cpu, o.Runtime.Memory})
 		}
 	}
 
-	if len(dcs) == 0 {
-		return nil, &NotFoundError{"datacenter", path}
+	if len(rps) == 0 {
+		return nil, &NotFoundError{"resource pool", joinPaths(paths)}
 	}
 
-	return dcs, nil
+	return rps, nil
 }
 
-func (f *Finder) Datacenter(ctx context.Context, path string) (*object.Datacenter, error) {
-	dcs, err := f.DatacenterList(ctx, path)
+func (f *Finder) ResourcePool(ctx context.Context, path string) (*object.ResourcePool, error) {
+	rps, err := f.ResourcePoolList(ctx, path)
 	if err != nil {
 		return nil, err
 	}
 
-	if len(dcs) > 1 {
-		return nil, &MultipleFoundError{"datacenter", path}
+	if len(rps) > 1 && !f.firstMatch {
+		return nil, &MultipleFoundError{"resource pool", path}
 	}
 
-	return dcs[0], nil
+	return rps[0], nil
 }
 
-func (f *Finder) DefaultDatacenter(ctx context.Context) (*object.Datacenter, error) {
-	dc, err := f.Datacenter(ctx, "*")
+// CreateResourcePoolPath is like "mkdir -p" for resource pools: the longest existing prefix of the
+// given path is resolved and each of the missing pools below it are created with the given spec.
+// The leaf pool is returned, whether it was created or already existed.
+func (f *Finder) CreateResourcePoolPath(ctx context.Context, p string, spec types.ResourceConfigSpec) (*object.ResourcePool, error) {
+	var missing []string
+
+	parent := path.Clean(p)
+
+	for {
+		pool, err := f.ResourcePool(ctx, parent)
+		if err == nil {
+			for i := len(missing) - 1; i >= 0; i-- {
+				child, err := pool.Create(ctx, missing[i], spec)
+				if err != nil {
+					return nil, err
+				}
+
+				child.InventoryPath = path.Join(pool.InventoryPath, missing[i])
+				pool = child
+			}
+
+			return pool, nil
+		}
+
+		if _, ok := err.(*NotFoundError); !ok {
+			return nil, err
+		}
+
+		dir := path.Dir(parent)
+		if dir == parent || dir == "." {
+			return nil, err
+		}
+
+		missing = append(missing, path.Base(parent))
+		parent = dir
+	}
+}
+
+func (f *Finder) DefaultResourcePool(ctx context.Context) (*object.ResourcePool, error) {
+	rp, err := f.resolver.DefaultResourcePool(ctx, f)
 	if err != nil {
 		return nil, toDefaultError(err)
 	}
 
-	return dc, nil
+	return rp, nil
 }
 
-func (f *Finder) DatacenterOrDefault(ctx context.Context, path string) (*object.Datacenter, error) {
+func (f *Finder) ResourcePoolOrDefault(ctx context.Context, path string) (*object.ResourcePool, error) {
 	if path != "" {
-		dc, err := f.Datacenter(ctx, path)
+		rp, err := f.ResourcePool(ctx, path)
 		if err != nil {
 			return nil, err
 		}
-		return dc, nil
+		return rp, nil
 	}
 
-	return f.DefaultDatacenter(ctx)
+	return f.DefaultResourcePool(ctx)
+}
+
+// ResourcePoolListAll combines ResourcePoolList and VirtualAppList
+// VirtualAppList is only called if ResourcePoolList does not find any pools with the given path.
+func (f *Finder) ResourcePoolListAll(ctx context.Context, paths ...string) ([]*object.ResourcePool, error) {
+	pools, err := f.ResourcePoolList(ctx, paths...)
+	if err != nil {
+		if _, ok := err.(*NotFoundError); !ok {
+			return nil, err
+		}
+
+		vapps, _ := f.VirtualAppList(ctx, paths...)
+
+		if len(vapps) == 0 {
+			return nil, err
+		}
+
+		for _, vapp := range vapps {
+			pools = append(pools, vapp.ResourcePool)
+		}
+	}
+
+	return pools, nil
+}
+
+// ResourcePoolNode is a ResourcePool along with its child pools, as returned by ResourcePoolTree.
+type ResourcePoolNode struct {
+	Pool     *object.ResourcePool
+	Children []*ResourcePoolNode
 }
 
-func (f *Finder) DatastoreList(ctx context.Context, path string) ([]*object.Datastore, error) {
-	es, err := f.find(ctx, f.datastoreFolder, false, path)
+// ResourcePoolTree returns the hierarchy of resource pools below the given root, such as the
+// root resource pool of a cluster.  The tree is retrieved via a single property collector request
+// traversing the resourcePool property.  VirtualApps are included as resource pools.
+func (f *Finder) ResourcePoolTree(ctx context.Context, root *object.ResourcePool) (*ResourcePoolNode, error) {
+	traversal := &types.TraversalSpec{
+		SelectionSpec: types.SelectionSpec{
+			Name: "resourcePoolTraversalSpec",
+		},
+		Type: "ResourcePool",
+		Path: "resourcePool",
+		SelectSet: []types.BaseSelectionSpec{
+			&types.SelectionSpec{
+				Name: "resourcePoolTraversalSpec",
+			},
+		},
+	}
+
+	req := types.RetrieveProperties{
+		SpecSet: []types.PropertyFilterSpec{
+			{
+				ObjectSet: []types.ObjectSpec{
+					{
+						Obj:       root.Reference(),
+						Skip:      types.NewBool(false),
+						SelectSet: []types.BaseSelectionSpec{traversal},
+					},
+				},
+				PropSet: []types.PropertySpec{
+					{Type: "ResourcePool", PathSet: []string{"name", "resourcePool"}},
+					{Type: "VirtualApp", PathSet: []string{"name", "resourcePool"}},
+				},
+			},
+		},
+	}
+
+	res, err := f.retrieveProperties(ctx, req)
 	if err != nil {
 		return nil, err
 	}
 
-	var dss []*object.Datastore
-	for _, e := range es {
-		ref := e.Object.Reference()
-		if ref.Type == "Datastore" {
-			ds := object.NewDatastore(f.client, ref)
-			ds.InventoryPath = e.Path
+	pools := make(map[types.ManagedObjectReference]mo.ResourcePool)
 
-			if f.dc == nil {
-				// In this case SetDatacenter was not called and path is absolute
-				ds.DatacenterPath, err = f.datacenterPath(ctx, ref)
-				if err != nil {
-					return nil, err
-				}
-			} else {
-				ds.DatacenterPath = f.dc.InventoryPath
-			}
+	for _, oc := range res.Returnval {
+		v, err := mo.ObjectContentToType(oc)
+		if err != nil {
+			return nil, err
+		}
 
-			dss = append(dss, ds)
+		switch o := v.(type) {
+		case mo.ResourcePool:
+			pools[o.Self] = o
+		case mo.VirtualApp:
+			pools[o.Self] = o.ResourcePool
 		}
 	}
 
-	if len(dss) == 0 {
-		return nil, &NotFoundError{"datastore", path}
+	var build func(types.ManagedObjectReference, string) *ResourcePoolNode
+
+	build = func(ref types.ManagedObjectReference, p string) *ResourcePoolNode {
+		node := &ResourcePoolNode{
+			Pool: object.NewResourcePool(f.client, ref),
+		}
+		node.Pool.InventoryPath = p
+
+		for _, child := range pools[ref].ResourcePool {
+			if c, ok := pools[child]; ok {
+				node.Children = append(node.Children, build(child, path.Join(p, c.Name)))
+			}
+		}
+
+		return node
 	}
 
-	return dss, nil
+	return build(root.Reference(), root.InventoryPath), nil
 }
 
-func (f *Finder) Datastore(ctx context.Context, path string) (*object.Datastore, error) {
-	dss, err := f.DatastoreList(ctx, path)
+// ResourcePoolDepth pairs a ResourcePool with its depth below the root of a tree, as returned by ResourcePoolListWithDepth.
+type ResourcePoolDepth struct {
+	Pool  *object.ResourcePool
+	Depth int
+}
+
+// ResourcePoolListWithDepth is like ResourcePoolTree, but returns the pools as a list in depth-first order,
+// each with its depth below the given root, which is included with depth 0.
+func (f *Finder) ResourcePoolListWithDepth(ctx context.Context, root *object.ResourcePool) ([]ResourcePoolDepth, error) {
+	tree, err := f.ResourcePoolTree(ctx, root)
 	if err != nil {
 		return nil, err
 	}
 
-	if len(dss) > 1 {
-		return nil, &MultipleFoundError{"datastore", path}
+	var pools []ResourcePoolDepth
+	var flatten func(*ResourcePoolNode, int)
+
+	flatten = func(node *ResourcePoolNode, depth int) {
+		pools = append(pools, ResourcePoolDepth{node.Pool, depth})
+		for _, child := range node.Children {
+			flatten(child, depth+1)
+		}
 	}
 
-	return dss[0], nil
+	flatten(tree, 0)
+
+	return pools, nil
 }
 
-func (f *Finder) DefaultDatastore(ctx context.Context) (*object.Datastore, error) {
-	ds, err := f.Datastore(ctx, "*")
+func (f *Finder) DefaultFolder(ctx context.Context) (*object.Folder, error) {
+	folder, err := f.resolver.DefaultFolder(ctx, f)
 	if err != nil {
 		return nil, toDefaultError(err)
 	}
 
-	return ds, nil
+	return folder, nil
 }
 
-func (f *Finder) DatastoreOrDefault(ctx context.Context, path string) (*object.Datastore, error) {
+func (f *Finder) FolderOrDefault(ctx context.Context, path string) (*object.Folder, error) {
 	if path != "" {
-		ds, err := f.Datastore(ctx, path)
+		folder, err := f.Folder(ctx, path)
 		if err != nil {
 			return nil, err
 		}
-		return ds, nil
+		return folder, nil
 	}
-
-	return f.DefaultDatastore(ctx)
+	return f.DefaultFolder(ctx)
 }
 
-func (f *Finder) DatastoreClusterList(ctx context.Context, path string) ([]*object.StoragePod, error) {
-	es, err := f.find(ctx, f.datastoreFolder, false, path)
+// VirtualMachineList returns the VMs matching any of the given paths, each VM only once
+// even when matched by several paths.  If no path is given, "*" is used.
+func (f *Finder) VirtualMachineList(ctx context.Context, paths ...string) ([]*object.VirtualMachine, error) {
+	if f.excludeSystemVMs {
+		vps, err := f.VirtualMachineListProps(ctx, nil, paths...)
+		if err != nil {
+			return nil, err
+		}
+
+		return virtualMachines(vps), nil
+	}
+
+	es, err := f.findPaths(ctx, f.vmFolder, false, nil, paths)
 	if err != nil {
 		return nil, err
 	}
 
-	var sps []*object.StoragePod
+	var vms []*object.VirtualMachine
 	for _, e := range es {
-		ref := e.Object.Reference()
-		if ref.Type == "StoragePod" {
-			sp := object.NewStoragePod(f.client, ref)
-			sp.InventoryPath = e.Path
-			sps = append(sps, sp)
+		switch o := e.Object.(type) {
+		case mo.VirtualMachine:
+			vm := object.NewVirtualMachine(f.client, o.Reference())
+			vm.InventoryPath = e.Path
+			vms = append(vms, vm)
 		}
 	}
 
-	if len(sps) == 0 {
-		return nil, &NotFoundError{"datastore cluster", path}
+	if len(vms) == 0 {
+		return nil, &NotFoundError{"vm", joinPaths(paths)}
 	}
 
-	return sps, nil
+	return vms, nil
 }
 
-func (f *Finder) DatastoreCluster(ctx context.Context, path string) (*object.StoragePod, error) {
-	sps, err := f.DatastoreClusterList(ctx, path)
-	if err != nil {
-		return nil, err
+// IsSystemVM returns true if the given VM is managed by the ESX Agent Manager as a cluster agent,
+// as is the case for vSphere Cluster Services (vCLS) VMs.  The config.managedBy property must be populated.
+func IsSystemVM(vm mo.VirtualMachine) bool {
+	if vm.Config == nil || vm.Config.ManagedBy == nil {
+		return false
 	}
 
-	if len(sps) > 1 {
-		return nil, &MultipleFoundError{"datastore cluster", path}
+	return vm.Config.ManagedBy.ExtensionKey == "com.vmware.vim.eam" && vm.Config.ManagedBy.Type == "cluster-agent"
+}
+
+func virtualMachines(vps []VirtualMachineProperties) []*object.VirtualMachine {
+	vms := make([]*object.VirtualMachine, len(vps))
+	for i := range vps {
+		vms[i] = vps[i].VirtualMachine
 	}
+	return vms
+}
 
-	return sps[0], nil
+// VirtualMachineResourcePool pairs a VirtualMachine with the resource pool it belongs to,
+// as returned by VirtualMachineListWithResourcePool.  ResourcePool is nil for templates.
+type VirtualMachineResourcePool struct {
+	VirtualMachine *object.VirtualMachine
+	ResourcePool   *object.ResourcePool
 }
 
-func (f *Finder) DefaultDatastoreCluster(ctx context.Context) (*object.StoragePod, error) {
-	sp, err := f.DatastoreCluster(ctx, "*")
+// VirtualMachineListWithResourcePool is like VirtualMachineList, but also returns the resource pool of each VM,
+// with its InventoryPath field set.  The resourcePool property is retrieved along with the VMs, and the paths of
+// all pools are resolved with a single property collector request, rather than walking the ancestors of each pool.
+func (f *Finder) VirtualMachineListWithResourcePool(ctx context.Context, paths ...string) ([]VirtualMachineResourcePool, error) {
+	vps, err := f.VirtualMachineListProps(ctx, []string{"resourcePool"}, paths...)
 	if err != nil {
-		return nil, toDefaultError(err)
+		return nil, err
 	}
 
-	return sp, nil
-}
-
-func (f *Finder) DatastoreClusterOrDefault(ctx context.Context, path string) (*object.StoragePod, error) {
-	if path != "" {
-		sp, err := f.DatastoreCluster(ctx, path)
-		if err != nil {
-			return nil, err
+	var pools []types.ManagedObjectReference
+	for _, vp := range vps {
+		if vp.Properties.ResourcePool != nil {
+			pools = append(pools, *vp.Properties.ResourcePool)
 		}
-		return sp, nil
 	}
 
-	return f.DefaultDatastoreCluster(ctx)
-}
-
-func (f *Finder) ComputeResourceList(ctx context.Context, path string) ([]*object.ComputeResource, error) {
-	es, err := f.find(ctx, f.hostFolder, false, path)
+	ppaths, err := f.displayPaths(ctx, pools)
 	if err != nil {
 		return nil, err
 	}
 
-	var crs []*object.ComputeResource
-	for _, e := range es {
-		var cr *object.ComputeResource
+	vms := make([]VirtualMachineResourcePool, len(vps))
+	for i, vp := range vps {
+		vms[i].VirtualMachine = vp.VirtualMachine
 
-		switch o := e.Object.(type) {
-		case mo.ComputeResource, mo.ClusterComputeResource:
-			cr = object.NewComputeResource(f.client, o.Reference())
-		default:
-			continue
+		if ref := vp.Properties.ResourcePool; ref != nil {
+			pool := object.NewResourcePool(f.client, *ref)
+			pool.InventoryPath = ppaths[*ref]
+			vms[i].ResourcePool = pool
 		}
+	}
 
-		cr.InventoryPath = e.Path
-		crs = append(crs, cr)
+	return vms, nil
+}
+
+// VirtualMachineListByPowerState is like VirtualMachineList, but includes only VMs in the given power state.
+func (f *Finder) VirtualMachineListByPowerState(ctx context.Context, state types.VirtualMachinePowerState, paths ...string) ([]*object.VirtualMachine, error) {
+	match := func(vm mo.VirtualMachine) bool {
+		return vm.Runtime.PowerState == state
 	}
 
-	if len(crs) == 0 {
-		return nil, &NotFoundError{"compute resource", path}
+	vps, err := f.VirtualMachineListProps(ctx, []string{"runtime.powerState"}, paths...)
+	if err != nil {
+		return nil, err
 	}
 
-	return crs, nil
+	return filterVirtualMachines(vps, match, paths)
 }
 
-func (f *Finder) ComputeResource(ctx context.Context, path string) (*object.ComputeResource, error) {
-	crs, err := f.ComputeResourceList(ctx, path)
+// VirtualMachineListByGuestIP is like VirtualMachineList, but includes only VMs where any guest NIC reports
+// the given IPv4 or IPv6 address.  Unlike SearchIndex.FindByIp, secondary addresses are matched as well.
+func (f *Finder) VirtualMachineListByGuestIP(ctx context.Context, ip string, paths ...string) ([]*object.VirtualMachine, error) {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return nil, fmt.Errorf("invalid IP address: %s", ip)
+	}
+
+	match := func(vm mo.VirtualMachine) bool {
+		if vm.Guest == nil {
+			return false
+		}
+
+		for _, nic := range vm.Guest.Net {
+			for _, a := range nic.IpAddress {
+				if addr.Equal(net.ParseIP(a)) {
+					return true
+				}
+			}
+		}
+
+		return false
+	}
+
+	vps, err := f.VirtualMachineListProps(ctx, []string{"guest.net"}, paths...)
 	if err != nil {
 		return nil, err
 	}
 
-	if len(crs) > 1 {
-		return nil, &MultipleFoundError{"compute resource", path}
-	}
+	return filterVirtualMachines(vps, match, paths)
+}
 
-	return crs[0], nil
+// VirtualMachineChangeVersion pairs a VirtualMachine with its config.changeVersion,
+// as returned by VirtualMachineListWithChangeVersion.
+type VirtualMachineChangeVersion struct {
+	VirtualMachine *object.VirtualMachine
+	ChangeVersion  string
 }
 
-func (f *Finder) DefaultComputeResource(ctx context.Context) (*object.ComputeResource, error) {
-	cr, err := f.ComputeResource(ctx, "*")
+// VirtualMachineListWithChangeVersion is shorthand for VirtualMachineListProps with config.changeVersion.
+// The version can be set in VirtualMachineConfigSpec.ChangeVersion to have a reconfigure fail if the VM
+// was modified since.
+func (f *Finder) VirtualMachineListWithChangeVersion(ctx context.Context, paths ...string) ([]VirtualMachineChangeVersion, error) {
+	vps, err := f.VirtualMachineListProps(ctx, []string{"config.changeVersion"}, paths...)
 	if err != nil {
-		return nil, toDefaultError(err)
+		return nil, err
 	}
 
-	return cr, nil
-}
-
-func (f *Finder) ComputeResourceOrDefault(ctx context.Context, path string) (*object.ComputeResource, error) {
-	if path != "" {
-		cr, err := f.ComputeResource(ctx, path)
-		if err != nil {
-			return nil, err
+	vms := make([]VirtualMachineChangeVersion, len(vps))
+	for i, vp := range vps {
+		vms[i].VirtualMachine = vp.VirtualMachine
+		if vp.Properties.Config != nil {
+			vms[i].ChangeVersion = vp.Properties.Config.ChangeVersion
 		}
-		return cr, nil
 	}
 
-	return f.DefaultComputeResource(ctx)
+	return vms, nil
+}
+
+// VirtualMachineHostname pairs a VirtualMachine with its guest.hostName, as returned by VirtualMachineListWithHostname.
+type VirtualMachineHostname struct {
+	VirtualMachine *object.VirtualMachine
+	Hostname       string
 }
 
-func (f *Finder) ClusterComputeResourceList(ctx context.Context, path string) ([]*object.ClusterComputeResource, error) {
-	es, err := f.find(ctx, f.hostFolder, false, path)
+// VirtualMachineListWithHostname is shorthand for VirtualMachineListProps with guest.hostName.  Hostname is
+// empty for VMs where it is not reported, such as those powered off or without VMware Tools running.
+func (f *Finder) VirtualMachineListWithHostname(ctx context.Context, paths ...string) ([]VirtualMachineHostname, error) {
+	vps, err := f.VirtualMachineListProps(ctx, []string{"guest.hostName"}, paths...)
 	if err != nil {
 		return nil, err
 	}
 
-	var ccrs []*object.ClusterComputeResource
-	for _, e := range es {
-		var ccr *object.ClusterComputeResource
-
-		switch o := e.Object.(type) {
-		case mo.ClusterComputeResource:
-			ccr = object.NewClusterComputeResource(f.client, o.Reference())
-		default:
-			continue
+	vms := make([]VirtualMachineHostname, len(vps))
+	for i, vp := range vps {
+		vms[i].VirtualMachine = vp.VirtualMachine
+		if vp.Properties.Guest != nil {
+			vms[i].Hostname = vp.Properties.Guest.HostName
 		}
-
-		ccr.InventoryPath = e.Path
-		ccrs = append(ccrs, ccr)
 	}
 
-	if len(ccrs) == 0 {
-		return nil, &NotFoundError{"cluster", path}
-	}
+	return vms, nil
+}
 
-	return ccrs, nil
+// VirtualMachineAnnotation pairs a VirtualMachine with its config.annotation, as returned by VirtualMachineListWithAnnotation.
+type VirtualMachineAnnotation struct {
+	VirtualMachine *object.VirtualMachine
+	Annotation     string
 }
 
-func (f *Finder) ClusterComputeResource(ctx context.Context, path string) (*object.ClusterComputeResource, error) {
-	ccrs, err := f.ClusterComputeResourceList(ctx, path)
+// VirtualMachineListWithAnnotation is shorthand for VirtualMachineListProps with config.annotation, the notes of each VM.
+func (f *Finder) VirtualMachineListWithAnnotation(ctx context.Context, paths ...string) ([]VirtualMachineAnnotation, error) {
+	vps, err := f.VirtualMachineListProps(ctx, []string{"config.annotation"}, paths...)
 	if err != nil {
 		return nil, err
 	}
 
-	if len(ccrs) > 1 {
-		return nil, &MultipleFoundError{"cluster", path}
+	vms := make([]VirtualMachineAnnotation, len(vps))
+	for i, vp := range vps {
+		vms[i].VirtualMachine = vp.VirtualMachine
+		if vp.Properties.Config != nil {
+			vms[i].Annotation = vp.Properties.Config.Annotation
+		}
 	}
 
-	return ccrs[0], nil
+	return vms, nil
 }
 
-func (f *Finder) HostSystemList(ctx context.Context, path string) ([]*object.HostSystem, error) {
-	es, err := f.find(ctx, f.hostFolder, false, path)
+// VirtualMachineToolsStatus pairs a VirtualMachine with the status of its VMware Tools,
+// as returned by VirtualMachineListWithToolsStatus.
+type VirtualMachineToolsStatus struct {
+	VirtualMachine     *object.VirtualMachine
+	ToolsStatus        types.VirtualMachineToolsStatus
+	ToolsVersionStatus string
+}
+
+// VirtualMachineListWithToolsStatus is shorthand for VirtualMachineListProps with guest.toolsStatus and
+// guest.toolsVersionStatus.  A powered off VM has a ToolsStatus of "toolsNotRunning"; both fields are empty if the guest info is not available.
+func (f *Finder) VirtualMachineListWithToolsStatus(ctx context.Context, paths ...string) ([]VirtualMachineToolsStatus, error) {
+	vps, err := f.VirtualMachineListProps(ctx, []string{"guest.toolsStatus", "guest.toolsVersionStatus"}, paths...)
 	if err != nil {
 		return nil, err
 	}
 
-	var hss []*object.HostSystem
-	for _, e := range es {
-		var hs *object.HostSystem
+	vms := make([]VirtualMachineToolsStatus, len(vps))
+	for i, vp := range vps {
+		vms[i].VirtualMachine = vp.VirtualMachine
+		if vp.Properties.Guest != nil {
+			vms[i].ToolsStatus = vp.Properties.Guest.ToolsStatus
+			vms[i].ToolsVersionStatus = vp.Properties.Guest.ToolsVersionStatus
+		}
+	}
 
-		switch o := e.Object.(type) {
-		case mo.HostSystem:
-			hs = object.NewHostSystem(f.client, o.Reference())
+	return vms, nil
+}
 
-			hs.InventoryPath = e.Path
-			hss = append(hss, hs)
-		case mo.ComputeResource, mo.ClusterComputeResource:
-			cr := object.NewComputeResource(f.client, o.Reference())
+// VirtualMachineProperties pairs a VirtualMachine with the properties requested from VirtualMachineListProps.
+type VirtualMachineProperties struct {
+	VirtualMachine *object.VirtualMachine
+	Properties     mo.VirtualMachine
+}
 
-			cr.InventoryPath = e.Path
+// VirtualMachineListProps is like VirtualMachineList, but also returns the given property paths of each VM,
+// such as "config.annotation" or "guest.hostName", retrieved along with the VMs in a single property collector
+// request.  Only the requested properties are set in Properties, along with config.managedBy when system VMs are excluded.
+func (f *Finder) VirtualMachineListProps(ctx context.Context, props []string, paths ...string) ([]VirtualMachineProperties, error) {
+	if f.excludeSystemVMs {
+		props = append([]string{"config.managedBy"}, props...)
+	}
 
-			hosts, err := cr.Hosts(ctx)
-			if err != nil {
-				return nil, err
+	kinds := map[string][]string{
+		"VirtualMachine": props,
+	}
+
+	es, err := f.findPaths(ctx, f.vmFolder, false, kinds, paths)
+	if err != nil {
+		return nil, err
+	}
+
+	var vms []VirtualMachineProperties
+	for _, e := range es {
+		switch o := e.Object.(type) {
+		case mo.VirtualMachine:
+			if f.excludeSystemVMs && IsSystemVM(o) {
+				continue
 			}
 
-			hss = append(hss, hosts...)
+			vm := object.NewVirtualMachine(f.client, o.Reference())
+			vm.InventoryPath = e.Path
+			vms = append(vms, VirtualMachineProperties{vm, o})
 		}
 	}
 
-	if len(hss) == 0 {
-		return nil, &NotFoundError{"host", path}
+	if len(vms) == 0 {
+		return nil, &NotFoundError{"vm", joinPaths(paths)}
 	}
 
-	return hss, nil
+	return vms, nil
 }
 
-func (f *Finder) HostSystem(ctx context.Context, path string) (*object.HostSystem, error) {
-	hss, err := f.HostSystemList(ctx, path)
-	if err != nil {
-		return nil, err
+// filterVirtualMachines returns the VMs for which match returns true, or a NotFoundError if there are none.
+func filterVirtualMachines(vps []VirtualMachineProperties, match func(mo.VirtualMachine) bool, paths []string) ([]*object.VirtualMachine, error) {
+	var vms []*object.VirtualMachine
+	for _, vp := range vps {
+		if match(vp.Properties) {
+			vms = append(vms, vp.VirtualMachine)
+		}
 	}
 
-	if len(hss) > 1 {
-		return nil, &MultipleFoundError{"host", path}
+	if len(vms) == 0 {
+		return nil, &NotFoundError{"vm", joinPaths(paths)}
 	}
 
-	return hss[0], nil
+	return vms, nil
 }
 
-func (f *Finder) DefaultHostSystem(ctx context.Context) (*object.HostSystem, error) {
-	hs, err := f.HostSystem(ctx, "*/*")
+// VMStats contains VM counts by power state and template status, as returned by VirtualMachineSummary.
+// Templates are counted in Templates only, not by power state.
+type VMStats struct {
+	Total      int
+	PoweredOn  int
+	PoweredOff int
+	Suspended  int
+	Templates  int
+}
+
+// VirtualMachineSummary counts the VMs matching the given paths by power state and template status,
+// retrieving both along with the VMs in a single property collector request.
+// Paths that match no VMs yield zero counts rather than a NotFoundError.
+func (f *Finder) VirtualMachineSummary(ctx context.Context, paths ...string) (VMStats, error) {
+	var stats VMStats
+
+	vps, err := f.VirtualMachineListProps(ctx, []string{"runtime.powerState", "config.template"}, paths...)
 	if err != nil {
-		return nil, toDefaultError(err)
+		if nf, ok := err.(*NotFoundError); ok && nf.kind == "vm" {
+			return stats, nil
+		}
+		return stats, err
 	}
 
-	return hs, nil
-}
+	for _, vp := range vps {
+		stats.Total++
 
-func (f *Finder) HostSystemOrDefault(ctx context.Context, path string) (*object.HostSystem, error) {
-	if path != "" {
-		hs, err := f.HostSystem(ctx, path)
-		if err != nil {
-			return nil, err
+		if vp.Properties.Config != nil && vp.Properties.Config.Template {
+			stats.Templates++
+			continue
+		}
+
+		switch vp.Properties.Runtime.PowerState {
+		case types.VirtualMachinePowerStatePoweredOn:
+			stats.PoweredOn++
+		case types.VirtualMachinePowerStatePoweredOff:
+			stats.PoweredOff++
+		case types.VirtualMachinePowerStateSuspended:
+			stats.Suspended++
 		}
-		return hs, nil
 	}
 
-	return f.DefaultHostSystem(ctx)
+	return stats, nil
 }
 
-func (f *Finder) NetworkList(ctx context.Context, path string) ([]object.NetworkReference, error) {
-	es, err := f.find(ctx, f.networkFolder, false, path)
+// VirtualMachineListByCustomField is like VirtualMachineList, but includes only VMs where the custom field
+// with the given name or key has the given value.
+func (f *Finder) VirtualMachineListByCustomField(ctx context.Context, key string, value string, paths ...string) ([]*object.VirtualMachine, error) {
+	m, err := object.GetCustomFieldsManager(f.client)
 	if err != nil {
 		return nil, err
 	}
 
-	var ns []object.NetworkReference
-	for _, e := range es {
-		ref := e.Object.Reference()
-		switch ref.Type {
-		case "Network", "OpaqueNetwork":
-			r := object.NewNetwork(f.client, ref)
-			r.InventoryPath = e.Path
-			ns = append(ns, r)
-		case "DistributedVirtualPortgroup":
-			r := object.NewDistributedVirtualPortgroup(f.client, ref)
-			r.InventoryPath = e.Path
-			ns = append(ns, r)
-		case "DistributedVirtualSwitch", "VmwareDistributedVirtualSwitch":
-			r := object.NewDistributedVirtualSwitch(f.client, ref)
-			r.InventoryPath = e.Path
-			ns = append(ns, r)
+	k, err := m.FindKey(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	match := func(vm mo.VirtualMachine) bool {
+		for _, cv := range vm.CustomValue {
+			if sv, ok := cv.(*types.CustomFieldStringValue); ok && sv.Key == k && sv.Value == value {
+				return true
+			}
 		}
+		return false
 	}
 
-	if len(ns) == 0 {
-		return nil, &NotFoundError{"network", path}
+	vps, err := f.VirtualMachineListProps(ctx, []string{"customValue"}, paths...)
+	if err != nil {
+		return nil, err
 	}
 
-	return ns, nil
+	return filterVirtualMachines(vps, match, paths)
 }
 
-func (f *Finder) Network(ctx context.Context, path string) (object.NetworkReference, error) {
-	networks, err := f.NetworkList(ctx, path)
+func (f *Finder) VirtualMachine(ctx context.Context, path string) (*object.VirtualMachine, error) {
+	vms, err := f.VirtualMachineList(ctx, path)
 	if err != nil {
 		return nil, err
 	}
 
-	if len(networks) > 1 {
-		return nil, &MultipleFoundError{"network", path}
+	if len(vms) > 1 && !f.firstMatch {
+		return nil, &MultipleFoundError{"vm", path}
 	}
 
-	return networks[0], nil
+	return vms[0], nil
 }
 
-func (f *Finder) DefaultNetwork(ctx context.Context) (object.NetworkReference, error) {
-	network, err := f.Network(ctx, "*")
-	if err != nil {
-		return nil, toDefaultError(err)
-	}
+// uuidCache holds the elements found by VirtualMachineByInstanceUuid, keyed by the datacenter searched
+// and instanceUuid.  It is safe for concurrent use, and a nil cache caches nothing.
+type uuidCache struct {
+	mu       sync.Mutex
+	elements map[uuidKey]list.Element
+}
 
-	return network, nil
+// uuidKey is the key of a uuidCache entry; dc is the zero value when searching all datacenters.
+type uuidKey struct {
+	dc   types.ManagedObjectReference
+	uuid string
 }
 
-func (f *Finder) NetworkOrDefault(ctx context.Context, path string) (object.NetworkReference, error) {
-	if path != "" {
-		network, err := f.Network(ctx, path)
-		if err != nil {
-			return nil, err
-		}
-		return network, nil
+func (c *uuidCache) get(k uuidKey) (list.Element, bool) {
+	if c == nil {
+		return list.Element{}, false
 	}
 
-	return f.DefaultNetwork(ctx)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.elements[k]
+	return e, ok
 }
 
-func (f *Finder) ResourcePoolList(ctx context.Context, path string) ([]*object.ResourcePool, error) {
-	es, err := f.find(ctx, f.hostFolder, true, path)
-	if err != nil {
-		return nil, err
+func (c *uuidCache) put(k uuidKey, e list.Element) {
+	if c == nil {
+		return
 	}
 
-	var rps []*object.ResourcePool
-	for _, e := range es {
-		var rp *object.ResourcePool
-
-		switch o := e.Object.(type) {
-		case mo.ResourcePool:
-			rp = object.NewResourcePool(f.client, o.Reference())
-			rp.InventoryPath = e.Path
-			rps = append(rps, rp)
-		}
-	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-	if len(rps) == 0 {
-		return nil, &NotFoundError{"resource pool", path}
+	if c.elements == nil {
+		c.elements = make(map[uuidKey]list.Element)
 	}
-
-	return rps, nil
+	c.elements[k] = e
 }
 
-func (f *Finder) ResourcePool(ctx context.Context, path string) (*object.ResourcePool, error) {
-	rps, err := f.ResourcePoolList(ctx, path)
-	if err != nil {
-		return nil, err
+func (c *uuidCache) reset() {
+	if c == nil {
+		return
 	}
 
-	if len(rps) > 1 {
-		return nil, &MultipleFoundError{"resource pool", path}
-	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-	return rps[0], nil
+	c.elements = nil
 }
 
-func (f *Finder) DefaultResourcePool(ctx context.Context) (*object.ResourcePool, error) {
-	rp, err := f.ResourcePool(ctx, "*/Resources")
-	if err != nil {
-		return nil, toDefaultError(err)
+// VirtualMachineByInstanceUuid returns the VM with the given instanceUuid, within the Finder's datacenter
+// if set, with its InventoryPath field set.  Results are cached by the Finder, such that repeated lookups
+// of the same uuid do not make any further requests, until Reset or SetDatacenter is called.
+// As such, a cached VM may no longer exist or may have moved since it was first found.
+// Concurrent lookups are safe, though they may each make requests for the same uncached uuid.
+func (f *Finder) VirtualMachineByInstanceUuid(ctx context.Context, uuid string) (*object.VirtualMachine, error) {
+	k := uuidKey{uuid: uuid}
+	if f.dc != nil {
+		k.dc = f.dc.Reference()
 	}
 
-	return rp, nil
-}
+	e, ok := f.instanceUuids.get(k)
+	if !ok {
+		ref, err := object.NewSearchIndex(f.client).FindByUuid(ctx, f.dc, uuid, true, types.NewBool(true))
+		if err != nil {
+			return nil, err
+		}
 
-func (f *Finder) ResourcePoolOrDefault(ctx context.Context, path string) (*object.ResourcePool, error) {
-	if path != "" {
-		rp, err := f.ResourcePool(ctx, path)
+		if ref == nil {
+			return nil, &NotFoundError{"vm", uuid}
+		}
+
+		p, err := f.Element(ctx, ref.Reference())
 		if err != nil {
 			return nil, err
 		}
-		return rp, nil
+
+		e = *p
+		f.instanceUuids.put(k, e)
 	}
 
-	return f.DefaultResourcePool(ctx)
+	vm := object.NewVirtualMachine(f.client, e.Object.Reference())
+	vm.InventoryPath = e.Path
+
+	return vm, nil
 }
 
-// ResourcePoolListAll combines ResourcePoolList and VirtualAppList
-// VirtualAppList is only called if ResourcePoolList does not find any pools with the given path.
-func (f *Finder) ResourcePoolListAll(ctx context.Context, path string) ([]*object.ResourcePool, error) {
-	pools, err := f.ResourcePoolList(ctx, path)
-	if err != nil {
+// VirtualMachineWait is like VirtualMachine, but if no VM matches the given path, the lookup is retried
+// until it does or the timeout elapses, for example while a VM that was just cloned becomes visible in
+// the inventory.  Errors other than NotFoundError are returned without retrying.
+func (f *Finder) VirtualMachineWait(ctx context.Context, path string, timeout time.Duration) (*object.VirtualMachine, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	delay := 100 * time.Millisecond
+
+	for {
+		vm, err := f.VirtualMachine(ctx, path)
+		if err == nil {
+			return vm, nil
+		}
+
 		if _, ok := err.(*NotFoundError); !ok {
 			return nil, err
 		}
 
-		vapps, _ := f.VirtualAppList(ctx, path)
-
-		if len(vapps) == 0 {
+		select {
+		case <-ctx.Done():
 			return nil, err
+		case <-time.After(delay):
 		}
 
-		for _, vapp := range vapps {
-			pools = append(pools, vapp.ResourcePool)
+		if delay < time.Second {
+			delay *= 2
 		}
 	}
-
-	return pools, nil
 }
 
-func (f *Finder) DefaultFolder(ctx context.Context) (*object.Folder, error) {
-	ref, err := f.vmFolder(ctx)
-	if err != nil {
-		return nil, toDefaultError(err)
+// VirtualMachineFuzzy is like VirtualMachine, but if no VM matches the given name or path, the VM with the name
+// closest to the base of name, by case-insensitive edit distance, is returned with approximate set to true.
+// A NotFoundError is still returned if no VM name is within an edit distance of at most half the number
+// of characters of the base of name, and a MultipleFoundError if more than one VM is equally close.
+func (f *Finder) VirtualMachineFuzzy(ctx context.Context, name string) (*object.VirtualMachine, bool, error) {
+	vm, err := f.VirtualMachine(ctx, name)
+	if err == nil {
+		return vm, false, nil
 	}
-	folder := object.NewFolder(f.client, ref.Reference())
 
-	return folder, nil
+	if _, ok := err.(*NotFoundError); !ok {
+		return nil, false, err
+	}
+
+	es, ferr := f.findAll(ctx, f.vmFolder)
+	if ferr != nil {
+		return nil, false, ferr
+	}
+
+	var candidates []list.Element
+	for _, e := range es {
+		if e.Object.Reference().Type == "VirtualMachine" {
+			candidates = append(candidates, e)
+		}
+	}
+
+	if f.excludeSystemVMs && len(candidates) != 0 {
+		refs := make([]types.ManagedObjectReference, len(candidates))
+		for i, e := range candidates {
+			refs[i] = e.Object.Reference()
+		}
+
+		var vms []mo.VirtualMachine
+		rerr := f.retrieve(ctx, refs, []string{"config.managedBy"}, &vms)
+		if rerr != nil {
+			return nil, false, rerr
+		}
+
+		system := make(map[types.ManagedObjectReference]bool)
+		for _, vm := range vms {
+			system[vm.Self] = IsSystemVM(vm)
+		}
+
+		var keep []list.Element
+		for _, e := range candidates {
+			if !system[e.Object.Reference()] {
+				keep = append(keep, e)
+			}
+		}
+		candidates = keep
+	}
+
+	base := strings.ToLower(path.Base(name))
+	limit := len([]rune(base)) / 2
+	best := 0
+	var matches []list.Element
+
+	for _, e := range candidates {
+		d := editDistance(base, strings.ToLower(path.Base(e.Path)))
+		switch {
+		case d > limit:
+			continue
+		case len(matches) == 0 || d < best:
+			best = d
+			matches = []list.Element{e}
+		case d == best:
+			matches = append(matches, e)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, false, err
+	case 1:
+		vm = object.NewVirtualMachine(f.client, matches[0].Object.Reference())
+		vm.InventoryPath = matches[0].Path
+		return vm, true, nil
+	default:
+		return nil, false, &MultipleFoundError{"vm", name}
+	}
 }
 
-func (f *Finder) FolderOrDefault(ctx context.Context, path string) (*object.Folder, error) {
-	if path != "" {
-		folder, err := f.Folder(ctx, path)
-		if err != nil {
-			return nil, err
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	x, y := []rune(a), []rune(b)
+	prev := make([]int, len(y)+1)
+	cur := make([]int, len(y)+1)
+
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(x); i++ {
+		cur[0] = i
+		for j := 1; j <= len(y); j++ {
+			cost := 1
+			if x[i-1] == y[j-1] {
+				cost = 0
+			}
+
+			cur[j] = prev[j-1] + cost
+			if prev[j]+1 < cur[j] {
+				cur[j] = prev[j] + 1
+			}
+			if cur[j-1]+1 < cur[j] {
+				cur[j] = cur[j-1] + 1
+			}
 		}
-		return folder, nil
+		prev, cur = cur, prev
 	}
-	return f.DefaultFolder(ctx)
+
+	return prev[len(y)]
 }
 
-func (f *Finder) VirtualMachineList(ctx context.Context, path string) ([]*object.VirtualMachine, error) {
-	es, err := f.find(ctx, f.vmFolder, false, path)
+// VirtualMachineListInFolder returns the VMs that are children of the given folder, with their InventoryPath set.
+// If recursive is true, VMs in any of its child folders are included as well.
+func (f *Finder) VirtualMachineListInFolder(ctx context.Context, folder *object.Folder, recursive bool) ([]*object.VirtualMachine, error) {
+	fn := func(_ context.Context) (object.Reference, error) {
+		return folder, nil
+	}
+
+	var es []list.Element
+	var err error
+
+	if recursive {
+		es, err = f.findAll(ctx, fn)
+	} else {
+		es, err = f.find(ctx, fn, true, ".")
+	}
 	if err != nil {
 		return nil, err
 	}
 
 	var vms []*object.VirtualMachine
 	for _, e := range es {
-		switch o := e.Object.(type) {
-		case mo.VirtualMachine:
-			vm := object.NewVirtualMachine(f.client, o.Reference())
+		ref := e.Object.Reference()
+		if ref.Type == "VirtualMachine" {
+			vm := object.NewVirtualMachine(f.client, ref)
 			vm.InventoryPath = e.Path
 			vms = append(vms, vm)
 		}
 	}
 
 	if len(vms) == 0 {
-		return nil, &NotFoundError{"vm", path}
+		return nil, &NotFoundError{"vm", folder.InventoryPath}
 	}
 
 	return vms, nil
 }
 
-func (f *Finder) VirtualMachine(ctx context.Context, path string) (*object.VirtualMachine, error) {
-	vms, err := f.VirtualMachineList(ctx, path)
+// VirtualMachineOrRef returns the VM with the given reference, if ref is not nil, with its InventoryPath
+// resolved via its ancestors.  Otherwise the VM is looked up by path, as VirtualMachine does.
+func (f *Finder) VirtualMachineOrRef(ctx context.Context, path string, ref *types.ManagedObjectReference) (*object.VirtualMachine, error) {
+	if ref == nil {
+		return f.VirtualMachine(ctx, path)
+	}
+
+	if ref.Type != "VirtualMachine" {
+		return nil, fmt.Errorf("%s is not a VirtualMachine", ref)
+	}
+
+	e, err := f.Element(ctx, *ref)
 	if err != nil {
 		return nil, err
 	}
 
-	if len(vms) > 1 {
-		return nil, &MultipleFoundError{"vm", path}
-	}
+	vm := object.NewVirtualMachine(f.client, *ref)
+	vm.InventoryPath = e.Path
 
-	return vms[0], nil
+	return vm, nil
 }
 
-func (f *Finder) VirtualAppList(ctx context.Context, path string) ([]*object.VirtualApp, error) {
-	es, err := f.find(ctx, f.vmFolder, false, path)
+func (f *Finder) VirtualAppList(ctx context.Context, paths ...string) ([]*object.VirtualApp, error) {
+	es, err := f.findPaths(ctx, f.vmFolder, false, nil, paths)
 	if err != nil {
 		return nil, err
 	}
@@ -856,7 +4169,7 @@ func (f *Finder) VirtualAppList(ctx context.Context, path string) ([]*object.Vir
 	}
 
 	if len(apps) == 0 {
-		return nil, &NotFoundError{"app", path}
+		return nil, &NotFoundError{"app", joinPaths(paths)}
 	}
 
 	return apps, nil
@@ -868,15 +4181,21 @@ func (f *Finder) VirtualApp(ctx context.Context, path string) (*object.VirtualAp
 		return nil, err
 	}
 
-	if len(apps) > 1 {
+	if len(apps) > 1 && !f.firstMatch {
 		return nil, &MultipleFoundError{"app", path}
 	}
 
 	return apps[0], nil
 }
 
-func (f *Finder) FolderList(ctx context.Context, path string) ([]*object.Folder, error) {
-	es, err := f.ManagedObjectList(ctx, path)
+func (f *Finder) FolderList(ctx context.Context, paths ...string) ([]*object.Folder, error) {
+	fn := f.rootFolder
+
+	if f.dc != nil {
+		fn = f.dcReference
+	}
+
+	es, err := f.findPaths(ctx, fn, false, nil, paths)
 	if err != nil {
 		return nil, err
 	}
@@ -896,7 +4215,7 @@ func (f *Finder) FolderList(ctx context.Context, path string) ([]*object.Folder,
 	}
 
 	if len(folders) == 0 {
-		return nil, &NotFoundError{"folder", path}
+		return nil, &NotFoundError{"folder", joinPaths(paths)}
 	}
 
 	return folders, nil
@@ -908,7 +4227,7 @@ func (f *Finder) Folder(ctx context.Context, path string) (*object.Folder, error
 		return nil, err
 	}
 
-	if len(folders) > 1 {
+	if len(folders) > 1 && !f.firstMatch {
 		return nil, &MultipleFoundError{"folder", path}
 	}
 
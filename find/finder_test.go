@@ -0,0 +1,1683 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package find
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/vmware/govmomi/list"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func TestCollapseNetworks(t *testing.T) {
+	backings := make(map[types.ManagedObjectReference]mo.Network)
+
+	network := func(kind, id, p string, hosts ...string) object.NetworkReference {
+		ref := types.ManagedObjectReference{Type: kind, Value: id}
+
+		if len(hosts) != 0 {
+			b := mo.Network{Summary: &types.NetworkSummary{Name: path.Base(p)}}
+			for _, h := range hosts {
+				b.Host = append(b.Host, types.ManagedObjectReference{Type: "HostSystem", Value: h})
+			}
+			backings[ref] = b
+		}
+
+		switch kind {
+		case "DistributedVirtualPortgroup":
+			pg := object.NewDistributedVirtualPortgroup(nil, ref)
+			pg.InventoryPath = p
+			return pg
+		default:
+			n := object.NewNetwork(nil, ref)
+			n.InventoryPath = p
+			return n
+		}
+	}
+
+	ns := []object.NetworkReference{
+		network("Network", "network-1", "/dc1/network/VM Network", "host-1"),
+		network("DistributedVirtualPortgroup", "dvportgroup-1", "/dc1/network/VM Network", "host-1", "host-2"),
+		network("Network", "network-2", "/dc1/network/Other", "host-1"),
+		network("OpaqueNetwork", "network-o1", "/dc1/network/DC0_DVPG0"),
+		network("DistributedVirtualPortgroup", "dvportgroup-2", "/dc1/network/DC0_DVPG0", "host-1"),
+		network("Network", "network-3", "/dc1/network/Lab", "host-3"),
+		network("DistributedVirtualPortgroup", "dvportgroup-3", "/dc1/network/Lab", "host-1"),
+		network("Network", "network-4", "/dc1/network/DC0_DVPG0"),
+	}
+
+	out := collapseNetworks(ns, backings)
+
+	expect := []string{"dvportgroup-1", "network-2", "network-o1", "dvportgroup-2", "network-3", "dvportgroup-3", "network-4"}
+	if len(out) != len(expect) {
+		t.Fatalf("expected %d networks, got %d", len(expect), len(out))
+	}
+
+	for i, n := range out {
+		if n.Reference().Value != expect[i] {
+			t.Errorf("%d: expected %s, got %s", i, expect[i], n.Reference().Value)
+		}
+	}
+}
+
+func TestVirtualMachineListNestedPath(t *testing.T) {
+	inv := newInventory()
+	dc := inv.add(inv.root, "Datacenter", "dc")
+	vmFolder := inv.add(dc, "Folder", "vm")
+	teamA := inv.add(vmFolder, "Folder", "teamA")
+	sub := inv.add(teamA, "Folder", "sub")
+	deep := inv.add(sub, "Folder", "deep")
+	inv.add(vmFolder, "VirtualMachine", "top")
+	inv.add(sub, "VirtualMachine", "vm1")
+	inv.add(deep, "VirtualMachine", "vm2")
+
+	tests := []struct {
+		path   string
+		expect []string
+	}{
+		{"*", []string{"/dc/vm/top"}},
+		{"teamA/sub/*", []string{"/dc/vm/teamA/sub/vm1"}},
+		{"teamA/sub/vm1", []string{"/dc/vm/teamA/sub/vm1"}},
+		{"teamA/*/deep/vm2", []string{"/dc/vm/teamA/sub/deep/vm2"}},
+		{"/dc/vm/teamA/sub/deep/*", []string{"/dc/vm/teamA/sub/deep/vm2"}},
+	}
+
+	ctx := context.Background()
+
+	for _, all := range []bool{false, true} {
+		f := inv.finder(all, dc)
+
+		for _, test := range tests {
+			vms, err := f.VirtualMachineList(ctx, test.path)
+			if err != nil {
+				t.Errorf("all=%t %s: %s", all, test.path, err)
+				continue
+			}
+
+			assertPaths(t, vms, test.expect)
+		}
+	}
+}
+
+func TestHostSystemListCluster(t *testing.T) {
+	inv := newInventory()
+	dc := inv.add(inv.root, "Datacenter", "dc")
+	hostFolder := inv.add(dc, "Folder", "host")
+	cluster := inv.add(hostFolder, "ClusterComputeResource", "cluster")
+	standalone := inv.add(hostFolder, "ComputeResource", "standalone")
+	inv.add(cluster, "HostSystem", "esx1")
+	inv.add(cluster, "HostSystem", "esx2")
+	inv.add(cluster, "HostSystem", "esx3")
+	esx4 := inv.add(standalone, "HostSystem", "esx4")
+
+	tests := []struct {
+		path   string
+		expect []string
+	}{
+		{"cluster", []string{"/dc/host/cluster/esx1", "/dc/host/cluster/esx2", "/dc/host/cluster/esx3"}},
+		{"standalone", []string{"/dc/host/standalone/esx4"}},
+		{"cluster/esx2", []string{"/dc/host/cluster/esx2"}},
+		{"/dc/host/" + cluster.String(), []string{"/dc/host/cluster/esx1", "/dc/host/cluster/esx2", "/dc/host/cluster/esx3"}},
+		{"*/" + esx4.String(), []string{"/dc/host/standalone/esx4"}},
+	}
+
+	ctx := context.Background()
+	f := inv.finder(false, dc)
+
+	for _, test := range tests {
+		hosts, err := f.HostSystemList(ctx, test.path)
+		if err != nil {
+			t.Errorf("%s: %s", test.path, err)
+			continue
+		}
+
+		assertPaths(t, hosts, test.expect)
+	}
+}
+
+func TestHostSystemListStandalone(t *testing.T) {
+	inv := newInventory()
+	dc := inv.add(inv.root, "Datacenter", "dc")
+	hostFolder := inv.add(dc, "Folder", "host")
+	folder := inv.add(hostFolder, "Folder", "lab")
+	cluster := inv.add(hostFolder, "ClusterComputeResource", "cluster")
+	inv.add(cluster, "HostSystem", "esx1")
+
+	// A standalone host is the single host of a ComputeResource of the same name
+	inv.add(inv.add(hostFolder, "ComputeResource", "esx2.example.com"), "HostSystem", "esx2.example.com")
+	inv.add(inv.add(folder, "ComputeResource", "esx3.example.com"), "HostSystem", "esx3.example.com")
+
+	tests := []struct {
+		path   string
+		expect []string
+	}{
+		{"esx2.example.com", []string{"/dc/host/esx2.example.com/esx2.example.com"}},
+		{"/dc/host/esx2.example.com", []string{"/dc/host/esx2.example.com/esx2.example.com"}},
+		{"esx2.example.com/esx2.example.com", []string{"/dc/host/esx2.example.com/esx2.example.com"}},
+		{"esx*", []string{"/dc/host/esx2.example.com/esx2.example.com"}},
+		{"lab/esx3.example.com", []string{"/dc/host/lab/esx3.example.com/esx3.example.com"}},
+		{"lab/*", []string{"/dc/host/lab/esx3.example.com/esx3.example.com"}},
+		{"*/*", []string{"/dc/host/lab/esx3.example.com/esx3.example.com", "/dc/host/cluster/esx1", "/dc/host/esx2.example.com/esx2.example.com"}},
+		{"*", []string{"/dc/host/cluster/esx1", "/dc/host/esx2.example.com/esx2.example.com"}},
+	}
+
+	ctx := context.Background()
+	f := inv.finder(false, dc)
+
+	for _, test := range tests {
+		hosts, err := f.HostSystemList(ctx, test.path)
+		if err != nil {
+			t.Errorf("%s: %s", test.path, err)
+			continue
+		}
+
+		assertPaths(t, hosts, test.expect)
+	}
+
+	host, err := f.HostSystem(ctx, "esx2.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if host.Name() != "esx2.example.com" {
+		t.Errorf("expected esx2.example.com, got %s", host.Name())
+	}
+}
+
+func TestHostSystemListClusterMembership(t *testing.T) {
+	inv := newInventory()
+	dc := inv.add(inv.root, "Datacenter", "dc")
+	hostFolder := inv.add(dc, "Folder", "host")
+	cluster := inv.add(hostFolder, "ClusterComputeResource", "cluster")
+	other := inv.add(hostFolder, "ClusterComputeResource", "other")
+	esx1 := inv.add(cluster, "HostSystem", "esx1")
+	esx2 := inv.add(other, "HostSystem", "esx2")
+
+	// cluster still lists esx2, which has moved to other
+	inv.hosts[cluster] = []types.ManagedObjectReference{esx1, esx2}
+
+	tests := []struct {
+		path   string
+		expect []string
+	}{
+		{"cluster", []string{"/dc/host/cluster/esx1"}},
+		{"other", []string{"/dc/host/other/esx2"}},
+		{"*", []string{"/dc/host/cluster/esx1", "/dc/host/other/esx2"}},
+	}
+
+	ctx := context.Background()
+	f := inv.finder(false, dc)
+
+	retrievals := 0
+	f.Client().RoundTripper = roundTripFunc(func(ctx context.Context, req, res soap.HasFault) error {
+		if body, ok := req.(*methods.RetrievePropertiesBody); ok {
+			for _, spec := range body.Req.SpecSet {
+				if len(spec.ObjectSet) != 0 && spec.ObjectSet[0].Obj.Type == "HostSystem" {
+					retrievals++
+				}
+			}
+		}
+		return inv.RoundTrip(ctx, req, res)
+	})
+
+	for _, test := range tests {
+		retrievals = 0
+
+		hosts, err := f.HostSystemList(ctx, test.path)
+		if err != nil {
+			t.Errorf("%s: %s", test.path, err)
+			continue
+		}
+
+		assertPaths(t, hosts, test.expect)
+
+		if retrievals != 1 {
+			t.Errorf("%s: expected 1 request for the hosts, got %d", test.path, retrievals)
+		}
+	}
+
+	// No members left
+	inv.hosts[cluster] = []types.ManagedObjectReference{esx2}
+
+	if _, err := f.HostSystemList(ctx, "cluster"); err == nil {
+		t.Error("expected error")
+	} else if _, ok := err.(*NotFoundError); !ok {
+		t.Errorf("expected NotFoundError, got %T", err)
+	}
+}
+
+func TestIsSystemVM(t *testing.T) {
+	vm := func(key, kind string) mo.VirtualMachine {
+		var o mo.VirtualMachine
+		if key != "" {
+			o.Config = &types.VirtualMachineConfigInfo{
+				ManagedBy: &types.ManagedByInfo{ExtensionKey: key, Type: kind},
+			}
+		}
+		return o
+	}
+
+	tests := []struct {
+		vm     mo.VirtualMachine
+		expect bool
+	}{
+		{mo.VirtualMachine{}, false},
+		{vm("", ""), false},
+		{vm("com.vmware.vim.eam", "cluster-agent"), true},
+		{vm("com.vmware.vim.eam", "other"), false},
+		{vm("com.example.backup", "cluster-agent"), false},
+	}
+
+	for i, test := range tests {
+		if IsSystemVM(test.vm) != test.expect {
+			t.Errorf("%d: expected %t", i, test.expect)
+		}
+	}
+}
+
+func TestHostSystemByName(t *testing.T) {
+	inv := newInventory()
+	dc := inv.add(inv.root, "Datacenter", "dc")
+	hostFolder := inv.add(dc, "Folder", "host")
+	east := inv.add(hostFolder, "Folder", "east")
+	west := inv.add(hostFolder, "Folder", "west")
+	c1 := inv.add(east, "ClusterComputeResource", "c1")
+	c2 := inv.add(west, "ClusterComputeResource", "c2")
+	standalone := inv.add(hostFolder, "ComputeResource", "standalone")
+	inv.add(c1, "HostSystem", "esx1")
+	inv.add(c1, "HostSystem", "dup")
+	inv.add(c2, "HostSystem", "esx2")
+	inv.add(c2, "HostSystem", "dup")
+	inv.add(standalone, "HostSystem", "esx3")
+
+	ctx := context.Background()
+	f := inv.finder(false, dc)
+
+	tests := map[string]string{
+		"esx1": "/dc/host/east/c1/esx1",
+		"esx2": "/dc/host/west/c2/esx2",
+		"esx3": "/dc/host/standalone/esx3",
+	}
+
+	for name, expect := range tests {
+		host, err := f.HostSystemByName(ctx, name)
+		if err != nil {
+			t.Errorf("%s: %s", name, err)
+			continue
+		}
+
+		if host.InventoryPath != expect {
+			t.Errorf("%s: expected %s, got %s", name, expect, host.InventoryPath)
+		}
+	}
+
+	if _, err := f.HostSystemByName(ctx, "dup"); err == nil {
+		t.Error("expected error")
+	} else if _, ok := err.(*MultipleFoundError); !ok {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	if _, err := f.HostSystemByName(ctx, "esx"); err == nil {
+		t.Error("expected error")
+	} else if _, ok := err.(*NotFoundError); !ok {
+		t.Errorf("unexpected error: %s", err)
+	}
+}
+
+func TestHasDatastoreFile(t *testing.T) {
+	vm := mo.VirtualMachine{
+		LayoutEx: &types.VirtualMachineFileLayoutEx{
+			File: []types.VirtualMachineFileLayoutExFileInfo{
+				{Name: "[ds1] vm/vm.vmx", Type: string(types.VirtualMachineFileLayoutExFileTypeConfig)},
+				{Name: "[ds1] vm/vm.vmdk", Type: string(types.VirtualMachineFileLayoutExFileTypeDiskDescriptor)},
+				{Name: "[ds2] vm/vm.vswp", Type: string(types.VirtualMachineFileLayoutExFileTypeSwap)},
+			},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		allFiles bool
+		expect   bool
+	}{
+		{"ds1", false, true},
+		{"ds1", true, true},
+		{"ds2", false, false},
+		{"ds2", true, true},
+		{"ds3", true, false},
+	}
+
+	for _, test := range tests {
+		if hasDatastoreFile(vm, test.name, test.allFiles) != test.expect {
+			t.Errorf("%s allFiles=%t: expected %t", test.name, test.allFiles, test.expect)
+		}
+	}
+
+	if hasDatastoreFile(mo.VirtualMachine{}, "ds1", false) {
+		t.Error("expected false without layoutEx")
+	}
+}
+
+func TestSetRoot(t *testing.T) {
+	inv := newInventory()
+	dc := inv.add(inv.root, "Datacenter", "dc")
+	vmFolder := inv.add(dc, "Folder", "vm")
+	tenant := inv.add(vmFolder, "Folder", "tenantA")
+	other := inv.add(vmFolder, "Folder", "tenantB")
+	vm1 := inv.add(tenant, "VirtualMachine", "vm1")
+	vm2 := inv.add(other, "VirtualMachine", "vm2")
+
+	ctx := context.Background()
+	f := inv.finder(false, dc)
+	f.SetRoot(object.NewFolder(f.client, tenant), "/tenantA")
+
+	for _, p := range []string{"*", "vm1", "/tenantA/*", "/tenantA/vm1"} {
+		vms, err := f.VirtualMachineList(ctx, p)
+		if err != nil {
+			t.Errorf("%s: %s", p, err)
+			continue
+		}
+
+		assertPaths(t, vms, []string{"/tenantA/vm1"})
+	}
+
+	for _, p := range []string{"/dc/vm/*", "/tenantB/*"} {
+		if _, err := f.VirtualMachineList(ctx, p); err == nil {
+			t.Errorf("%s: expected error", p)
+		}
+	}
+
+	e, err := f.Element(ctx, vm1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Path != "/tenantA/vm1" {
+		t.Errorf("unexpected path %s", e.Path)
+	}
+
+	for _, ref := range []types.ManagedObjectReference{vm2, vmFolder} {
+		if _, err := f.Element(ctx, ref); err == nil {
+			t.Errorf("%s: expected error", ref)
+		}
+	}
+
+	// A typed nil restores the root folder, as does an untyped nil
+	for _, root := range []object.Reference{(*object.Folder)(nil), nil} {
+		f.SetRoot(object.NewFolder(f.client, tenant), "/tenantA")
+		f.SetRoot(root, "")
+
+		vms, err := f.VirtualMachineList(ctx, "/dc/vm/*/*")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assertPaths(t, vms, []string{"/dc/vm/tenantA/vm1", "/dc/vm/tenantB/vm2"})
+	}
+}
+
+func TestComplete(t *testing.T) {
+	inv := newInventory()
+	dc := inv.add(inv.root, "Datacenter", "dc")
+	vmFolder := inv.add(dc, "Folder", "vm")
+	inv.add(dc, "Folder", "host")
+	teamA := inv.add(vmFolder, "Folder", "teamA")
+	inv.add(vmFolder, "Folder", "teamB")
+	inv.add(vmFolder, "VirtualMachine", "test-vm")
+	inv.add(teamA, "VirtualMachine", "web1")
+	inv.add(teamA, "VirtualMachine", "web2")
+
+	tests := []struct {
+		partial string
+		expect  []string
+	}{
+		{"", []string{"host/", "vm/"}},
+		{"v", []string{"vm/"}},
+		{"vm/", []string{"vm/teamA/", "vm/teamB/", "vm/test-vm"}},
+		{"vm/te", []string{"vm/teamA/", "vm/teamB/", "vm/test-vm"}},
+		{"vm/teamA/w", []string{"vm/teamA/web1", "vm/teamA/web2"}},
+		{"vm/teamA/x", nil},
+		{"/d", []string{"/dc/"}},
+		{"/dc/vm/teamA/web1", []string{"/dc/vm/teamA/web1"}},
+		{"vm/team*/w", []string{"vm/teamA/web1", "vm/teamA/web2"}},
+		{"./vm/t*/", []string{"./vm/teamA/web1", "./vm/teamA/web2"}},
+		{"/dc/*/team?/web2", []string{"/dc/vm/teamA/web2"}},
+	}
+
+	ctx := context.Background()
+	f := inv.finder(false, dc)
+
+	for _, test := range tests {
+		paths, err := f.Complete(ctx, test.partial)
+		if err != nil {
+			t.Errorf("%q: %s", test.partial, err)
+			continue
+		}
+
+		assertPaths(t, paths, test.expect)
+	}
+}
+
+func TestVirtualMachineListInFolder(t *testing.T) {
+	inv := newInventory()
+	dc := inv.add(inv.root, "Datacenter", "dc")
+	vmFolder := inv.add(dc, "Folder", "vm")
+	teamA := inv.add(vmFolder, "Folder", "teamA")
+	sub := inv.add(teamA, "Folder", "sub")
+	inv.add(teamA, "VirtualMachine", "vm1")
+	inv.add(sub, "VirtualMachine", "vm2")
+
+	ctx := context.Background()
+	f := inv.finder(false, dc)
+	folder := object.NewFolder(f.client, teamA)
+
+	tests := []struct {
+		recursive bool
+		expect    []string
+	}{
+		{false, []string{"/dc/vm/teamA/vm1"}},
+		{true, []string{"/dc/vm/teamA/vm1", "/dc/vm/teamA/sub/vm2"}},
+	}
+
+	for _, test := range tests {
+		vms, err := f.VirtualMachineListInFolder(ctx, folder, test.recursive)
+		if err != nil {
+			t.Errorf("recursive=%t: %s", test.recursive, err)
+			continue
+		}
+
+		assertPaths(t, vms, test.expect)
+	}
+}
+
+func TestByFreeSpace(t *testing.T) {
+	ds := func(name string, free int64) DatastoreFreeSpace {
+		d := object.NewDatastore(nil, types.ManagedObjectReference{Type: "Datastore", Value: name})
+		d.InventoryPath = "/dc/datastore/" + name
+		return DatastoreFreeSpace{d, free}
+	}
+
+	free := []DatastoreFreeSpace{
+		ds("c", 10),
+		ds("b", 20),
+		ds("a", 10),
+		ds("d", 30),
+	}
+
+	sort.Sort(byFreeSpace(free))
+
+	expect := []string{"d", "b", "a", "c"}
+	for i, ds := range free {
+		if ds.Datastore.Name() != expect[i] {
+			t.Errorf("%d: expected %s, got %s", i, expect[i], ds.Datastore.Name())
+		}
+	}
+}
+
+func TestVirtualMachineListInferDatacenter(t *testing.T) {
+	inv := newInventory()
+	for _, name := range []string{"dc1", "dc2"} {
+		dc := inv.add(inv.root, "Datacenter", name)
+		vmFolder := inv.add(dc, "Folder", "vm")
+		folder := inv.add(vmFolder, "Folder", "web")
+		inv.add(folder, "VirtualMachine", "vm-"+name)
+	}
+
+	tests := []struct {
+		path   string
+		expect []string
+	}{
+		{"dc1/web/*", []string{"/dc1/vm/web/vm-dc1"}},
+		{"dc2/web/vm-dc2", []string{"/dc2/vm/web/vm-dc2"}},
+		{"dc1/vm/web/*", []string{"/dc1/vm/web/vm-dc1"}},
+		{"dc*/vm/web/*", []string{"/dc1/vm/web/vm-dc1", "/dc2/vm/web/vm-dc2"}},
+	}
+
+	ctx := context.Background()
+	f := inv.finder(false, types.ManagedObjectReference{})
+
+	for _, test := range tests {
+		vms, err := f.VirtualMachineList(ctx, test.path)
+		if err != nil {
+			t.Errorf("%s: %s", test.path, err)
+			continue
+		}
+
+		assertPaths(t, vms, test.expect)
+	}
+
+	for _, p := range []string{"web/*", "dc*/web/*", "dc3/web/*"} {
+		if _, err := f.VirtualMachineList(ctx, p); err == nil {
+			t.Errorf("%s: expected error", p)
+		}
+	}
+}
+
+func TestNetworkListByBinding(t *testing.T) {
+	inv := newInventory()
+	dc := inv.add(inv.root, "Datacenter", "dc")
+	networkFolder := inv.add(dc, "Folder", "network")
+	inv.add(networkFolder, "Network", "VM Network")
+
+	for i, binding := range []string{"earlyBinding", "ephemeral", "earlyBinding"} {
+		pg := inv.add(networkFolder, "DistributedVirtualPortgroup", fmt.Sprintf("pg%d-%s", i+1, binding))
+		inv.props[pg] = []types.DynamicProperty{{Name: "config.type", Val: binding}}
+	}
+
+	tests := []struct {
+		binding string
+		expect  []string
+	}{
+		{"earlyBinding", []string{"/dc/network/pg1-earlyBinding", "/dc/network/pg3-earlyBinding"}},
+		{"ephemeral", []string{"/dc/network/pg2-ephemeral"}},
+	}
+
+	ctx := context.Background()
+	f := inv.finder(false, dc)
+
+	for _, test := range tests {
+		pgs, err := f.NetworkListByBinding(ctx, test.binding)
+		if err != nil {
+			t.Errorf("%s: %s", test.binding, err)
+			continue
+		}
+
+		assertPaths(t, pgs, test.expect)
+	}
+
+	if _, err := f.NetworkListByBinding(ctx, "lateBinding"); err == nil {
+		t.Error("lateBinding: expected error")
+	}
+
+	if _, err := f.NetworkListByBinding(ctx, "static"); err == nil {
+		t.Error("static: expected error")
+	}
+}
+
+func TestDefaultNetwork(t *testing.T) {
+	inv := newInventory()
+	dc := inv.add(inv.root, "Datacenter", "dc")
+	hostFolder := inv.add(dc, "Folder", "host")
+	networkFolder := inv.add(dc, "Folder", "network")
+	cluster := inv.add(hostFolder, "ClusterComputeResource", "cluster")
+	inv.add(networkFolder, "Network", "VM Network")
+	inv.add(networkFolder, "VmwareDistributedVirtualSwitch", "dvs")
+	pg := inv.add(networkFolder, "DistributedVirtualPortgroup", "pg")
+	uplink := inv.add(networkFolder, "DistributedVirtualPortgroup", "dvs-uplinks")
+	inv.props[uplink] = []types.DynamicProperty{{Name: "config.uplink", Val: true}}
+
+	ctx := context.Background()
+	f := inv.finder(false, dc)
+
+	// The cluster is not connected to any network
+	_, err := f.DefaultNetwork(ctx)
+	if _, ok := err.(*DefaultMultipleFoundError); !ok {
+		t.Fatalf("expected DefaultMultipleFoundError, got %v", err)
+	}
+
+	expect := "default network resolves to multiple instances (/dc/network/VM Network, /dc/network/pg, /dc/network/dvs-uplinks), please specify"
+	if err.Error() != expect {
+		t.Errorf("expected %q, got %q", expect, err)
+	}
+
+	f.SetFirstMatch(true)
+
+	n, err := f.DefaultNetwork(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n.(*object.Network).InventoryPath != "/dc/network/VM Network" {
+		t.Errorf("unexpected network: %s", n.Reference())
+	}
+
+	f.SetFirstMatch(false)
+
+	inv.props[cluster] = []types.DynamicProperty{{
+		Name: "network",
+		Val:  types.ArrayOfManagedObjectReference{ManagedObjectReference: []types.ManagedObjectReference{pg, uplink}},
+	}}
+
+	n, err = f.DefaultNetwork(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n.Reference() != pg {
+		t.Errorf("expected %s, got %s", pg, n.Reference())
+	}
+}
+
+func TestNetworkListCollapse(t *testing.T) {
+	inv := newInventory()
+	dc := inv.add(inv.root, "Datacenter", "dc")
+	netFolder := inv.add(dc, "Folder", "network")
+	host := types.ManagedObjectReference{Type: "HostSystem", Value: "host-1"}
+
+	for _, kind := range []string{"Network", "DistributedVirtualPortgroup"} {
+		ref := inv.add(netFolder, kind, "VM Network")
+		inv.props[ref] = []types.DynamicProperty{
+			{Name: "summary", Val: types.NetworkSummary{Name: "VM Network"}},
+			{Name: "host", Val: types.ArrayOfManagedObjectReference{ManagedObjectReference: []types.ManagedObjectReference{host}}},
+		}
+	}
+
+	ctx := context.Background()
+	f := inv.finder(false, dc)
+
+	ns, err := f.NetworkList(ctx, "*")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(ns) != 2 {
+		t.Errorf("expected 2 networks, got %d", len(ns))
+	}
+
+	ns, err = f.SetCollapseNetworks(true).NetworkList(ctx, "*")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(ns) != 1 || ns[0].Reference().Type != "DistributedVirtualPortgroup" {
+		t.Errorf("expected a single portgroup, got %v", ns)
+	}
+}
+
+func TestNames(t *testing.T) {
+	inv := newInventory()
+	dc := inv.add(inv.root, "Datacenter", "dc")
+	vmFolder := inv.add(dc, "Folder", "vm")
+	hostFolder := inv.add(dc, "Folder", "host")
+	vm := inv.add(vmFolder, "VirtualMachine", "vm1")
+	host := inv.add(hostFolder, "HostSystem", "esx1")
+
+	ctx := context.Background()
+	f := inv.finder(false, dc)
+
+	names, err := f.Names(ctx, []types.ManagedObjectReference{vm, host, vmFolder, vm})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := map[types.ManagedObjectReference]string{
+		vm:       "vm1",
+		host:     "esx1",
+		vmFolder: "vm",
+	}
+
+	if len(names) != len(expect) {
+		t.Errorf("expected %d names, got %d", len(expect), len(names))
+	}
+
+	for ref, name := range expect {
+		if names[ref] != name {
+			t.Errorf("%s: expected %s, got %s", ref, name, names[ref])
+		}
+	}
+
+	names, err = f.Names(ctx, nil)
+	if err != nil || len(names) != 0 {
+		t.Errorf("expected no names, got %v (%v)", names, err)
+	}
+}
+
+func TestVirtualMachineListWithAnnotation(t *testing.T) {
+	inv := newInventory()
+	dc := inv.add(inv.root, "Datacenter", "dc")
+	vmFolder := inv.add(dc, "Folder", "vm")
+	vm1 := inv.add(vmFolder, "VirtualMachine", "vm1")
+	inv.add(vmFolder, "VirtualMachine", "vm2")
+
+	notes := strings.Repeat("line of notes\n", 512)
+	inv.props[vm1] = []types.DynamicProperty{{Name: "config.annotation", Val: notes}}
+
+	ctx := context.Background()
+	f := inv.finder(false, dc)
+
+	vms, err := f.VirtualMachineListWithAnnotation(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(vms) != 2 {
+		t.Fatalf("expected 2 vms, got %d", len(vms))
+	}
+
+	if vms[0].Annotation != notes {
+		t.Errorf("expected annotation of %d bytes, got %d", len(notes), len(vms[0].Annotation))
+	}
+
+	if vms[1].Annotation != "" {
+		t.Errorf("expected empty annotation, got %q", vms[1].Annotation)
+	}
+}
+
+func TestDatastoreListAvailable(t *testing.T) {
+	inv := newInventory()
+	dc := inv.add(inv.root, "Datacenter", "dc")
+	datastoreFolder := inv.add(dc, "Folder", "datastore")
+
+	for _, mode := range []string{"normal", "enteringMaintenance", "inMaintenance", ""} {
+		ds := inv.add(datastoreFolder, "Datastore", "ds-"+mode)
+		if mode != "" {
+			inv.props[ds] = []types.DynamicProperty{{Name: "summary.maintenanceMode", Val: mode}}
+		}
+	}
+
+	ctx := context.Background()
+	f := inv.finder(false, dc)
+
+	dss, err := f.DatastoreListAvailable(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := []string{"/dc/datastore/ds-normal", "/dc/datastore/ds-"}
+
+	assertPaths(t, dss, expect)
+
+	if _, err = f.DatastoreListAvailable(ctx, "ds-in*"); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b   string
+		expect int
+	}{
+		{"", "", 0},
+		{"web", "", 3},
+		{"", "web", 3},
+		{"web01", "web01", 0},
+		{"web01", "web1", 1},
+		{"web01", "wbe01", 2},
+		{"kitten", "sitting", 3},
+		{"naïve", "naive", 1},
+	}
+
+	for _, test := range tests {
+		if d := editDistance(test.a, test.b); d != test.expect {
+			t.Errorf("%q, %q: expected %d, got %d", test.a, test.b, test.expect, d)
+		}
+	}
+}
+
+func TestVirtualMachineFuzzy(t *testing.T) {
+	inv := newInventory()
+	dc := inv.add(inv.root, "Datacenter", "dc")
+	vmFolder := inv.add(dc, "Folder", "vm")
+	folder := inv.add(vmFolder, "Folder", "web")
+	inv.add(folder, "VirtualMachine", "web01")
+	inv.add(folder, "VirtualMachine", "web02")
+	inv.add(vmFolder, "VirtualMachine", "database")
+	inv.add(vmFolder, "VirtualMachine", "qrst99")
+	inv.add(vmFolder, "VirtualMachine", "abxyz")
+	inv.add(vmFolder, "VirtualMachine", "xyz")
+
+	tests := []struct {
+		name        string
+		expect      string
+		approximate bool
+	}{
+		{"qrst", "/dc/vm/qrst99", true}, // distance 2 of 4 characters
+		{"web/web01", "/dc/vm/web/web01", false},
+		{"database", "/dc/vm/database", false},
+		{"databse", "/dc/vm/database", true},
+		{"DataBase", "/dc/vm/database", true},
+		{"web/web022", "/dc/vm/web/web02", true},
+	}
+
+	ctx := context.Background()
+	f := inv.finder(false, dc)
+
+	for _, test := range tests {
+		vm, approximate, err := f.VirtualMachineFuzzy(ctx, test.name)
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err)
+			continue
+		}
+
+		if vm.InventoryPath != test.expect || approximate != test.approximate {
+			t.Errorf("%s: expected %s (%t), got %s (%t)", test.name, test.expect, test.approximate, vm.InventoryPath, approximate)
+		}
+	}
+
+	// Equally close to web01 and web02
+	if _, _, err := f.VirtualMachineFuzzy(ctx, "web0"); err == nil {
+		t.Error("web0: expected error")
+	} else if _, ok := err.(*MultipleFoundError); !ok {
+		t.Errorf("web0: expected MultipleFoundError, got %T", err)
+	}
+
+	// "abcd" is at distance 3 of "abxyz", "ñññ" at distance 3 of "xyz", both more than half their length
+	for _, name := range []string{"mail", "abcd", "ñññ"} {
+		if _, _, err := f.VirtualMachineFuzzy(ctx, name); err == nil {
+			t.Errorf("%s: expected error", name)
+		} else if _, ok := err.(*NotFoundError); !ok {
+			t.Errorf("%s: expected NotFoundError, got %T", name, err)
+		}
+	}
+}
+
+func TestClusterComputeResourceListWithVsan(t *testing.T) {
+	inv := newInventory()
+	dc := inv.add(inv.root, "Datacenter", "dc")
+	hostFolder := inv.add(dc, "Folder", "host")
+	inv.add(hostFolder, "ComputeResource", "standalone")
+
+	for name, vsan := range map[string]*types.VsanClusterConfigInfo{
+		"c1": {Enabled: types.NewBool(true)},
+		"c2": {Enabled: types.NewBool(false)},
+		"c3": nil,
+	} {
+		ccr := inv.add(hostFolder, "ClusterComputeResource", name)
+		inv.props[ccr] = []types.DynamicProperty{{
+			Name: "configurationEx",
+			Val:  &types.ClusterConfigInfoEx{VsanConfigInfo: vsan},
+		}}
+	}
+
+	ctx := context.Background()
+	f := inv.finder(false, dc)
+
+	ccrs, err := f.ClusterComputeResourceListWithVsan(ctx, "*")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(ccrs) != 3 {
+		t.Fatalf("expected 3 clusters, got %d", len(ccrs))
+	}
+
+	for _, c := range ccrs {
+		expect := c.Cluster.Name() == "c1"
+		if c.VsanEnabled != expect {
+			t.Errorf("%s: expected %t, got %t", c.Cluster.Name(), expect, c.VsanEnabled)
+		}
+	}
+}
+
+func TestNetworkListExcludeUplinks(t *testing.T) {
+	inv := newInventory()
+	dc := inv.add(inv.root, "Datacenter", "dc")
+	networkFolder := inv.add(dc, "Folder", "network")
+	inv.add(networkFolder, "Network", "VM Network")
+	pg := inv.add(networkFolder, "DistributedVirtualPortgroup", "pg")
+	uplink := inv.add(networkFolder, "DistributedVirtualPortgroup", "dvs-uplinks")
+	inv.props[pg] = []types.DynamicProperty{{Name: "config.uplink", Val: false}}
+	inv.props[uplink] = []types.DynamicProperty{{Name: "config.uplink", Val: true}}
+
+	ctx := context.Background()
+	f := inv.finder(false, dc)
+
+	for _, exclude := range []bool{false, true} {
+		f.SetExcludeUplinks(exclude)
+
+		ns, err := f.NetworkList(ctx, "*")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expect := []string{"/dc/network/VM Network", "/dc/network/pg", "/dc/network/dvs-uplinks"}
+		if exclude {
+			expect = expect[:2]
+		}
+
+		assertPaths(t, ns, expect)
+	}
+}
+
+func TestVirtualMachineListProps(t *testing.T) {
+	inv := newInventory()
+	dc := inv.add(inv.root, "Datacenter", "dc")
+	vmFolder := inv.add(dc, "Folder", "vm")
+	vm := inv.add(vmFolder, "VirtualMachine", "vm1")
+	inv.props[vm] = []types.DynamicProperty{
+		{Name: "guest.hostName", Val: "vm1.example.com"},
+		{Name: "runtime.powerState", Val: types.VirtualMachinePowerStatePoweredOn},
+	}
+
+	ctx := context.Background()
+	f := inv.finder(false, dc)
+
+	vms, err := f.VirtualMachineListProps(ctx, []string{"guest.hostName", "runtime.powerState"}, "vm1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(vms) != 1 {
+		t.Fatalf("expected 1 vm, got %d", len(vms))
+	}
+
+	p := vms[0].Properties
+	if p.Guest == nil || p.Guest.HostName != "vm1.example.com" {
+		t.Errorf("unexpected guest: %#v", p.Guest)
+	}
+
+	if p.Runtime.PowerState != types.VirtualMachinePowerStatePoweredOn {
+		t.Errorf("unexpected power state: %s", p.Runtime.PowerState)
+	}
+
+	if vms[0].VirtualMachine.InventoryPath != "/dc/vm/vm1" {
+		t.Errorf("unexpected path: %s", vms[0].VirtualMachine.InventoryPath)
+	}
+}
+
+func TestVirtualMachineWait(t *testing.T) {
+	inv := newInventory()
+	dc := inv.add(inv.root, "Datacenter", "dc")
+	vmFolder := inv.add(dc, "Folder", "vm")
+
+	ctx := context.Background()
+	f := inv.finder(false, dc)
+
+	// The VM appears after a few lookups
+	lookups := 0
+	f.Client().RoundTripper = roundTripFunc(func(ctx context.Context, req, res soap.HasFault) error {
+		lookups++
+		if lookups == 3 {
+			inv.add(vmFolder, "VirtualMachine", "clone")
+		}
+		return inv.RoundTrip(ctx, req, res)
+	})
+
+	vm, err := f.VirtualMachineWait(ctx, "clone", 10*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if vm.InventoryPath != "/dc/vm/clone" {
+		t.Errorf("unexpected path: %s", vm.InventoryPath)
+	}
+
+	_, err = f.VirtualMachineWait(ctx, "enoent", 150*time.Millisecond)
+	if _, ok := err.(*NotFoundError); !ok {
+		t.Errorf("expected NotFoundError, got %v", err)
+	}
+}
+
+func TestDatacenterListSameName(t *testing.T) {
+	inv := newInventory()
+	inv.add(inv.add(inv.root, "Folder", "b"), "Datacenter", "dc")
+	inv.add(inv.add(inv.root, "Folder", "a"), "Datacenter", "dc")
+	inv.add(inv.root, "Datacenter", "c")
+
+	ctx := context.Background()
+	f := inv.finder(false, types.ManagedObjectReference{})
+
+	dcs, err := f.DatacenterList(ctx, "/b/dc", "/a/dc", "/c")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertPaths(t, pathsOf(dcs), []string{"/c", "/a/dc", "/b/dc"})
+}
+
+func TestDatacenterListPrivileges(t *testing.T) {
+	inv := newInventory()
+	dc1 := inv.add(inv.root, "Datacenter", "dc1")
+	inv.add(inv.root, "Datacenter", "dc2")
+
+	sm := types.ManagedObjectReference{Type: "SessionManager", Value: "SessionManager"}
+	am := types.ManagedObjectReference{Type: "AuthorizationManager", Value: "AuthorizationManager"}
+	inv.props[sm] = []types.DynamicProperty{{Name: "currentSession", Val: types.UserSession{Key: "session-1"}}}
+
+	ctx := context.Background()
+	f := inv.finder(false, types.ManagedObjectReference{})
+
+	c := f.Client()
+	c.ServiceContent.SessionManager = &sm
+	c.ServiceContent.AuthorizationManager = &am
+	c.RoundTripper = roundTripFunc(func(ctx context.Context, req, res soap.HasFault) error {
+		body, ok := req.(*methods.HasPrivilegeOnEntitiesBody)
+		if !ok {
+			return inv.RoundTrip(ctx, req, res)
+		}
+
+		if body.Req.SessionId != "session-1" {
+			t.Errorf("unexpected session: %s", body.Req.SessionId)
+		}
+
+		// Only dc1 is granted all privileges
+		var privs []types.EntityPrivilege
+		for _, e := range body.Req.Entity {
+			p := types.EntityPrivilege{Entity: e}
+			for i, id := range body.Req.PrivId {
+				p.PrivAvailability = append(p.PrivAvailability, types.PrivilegeAvailability{
+					PrivId:    id,
+					IsGranted: e == dc1 || i == 0,
+				})
+			}
+			privs = append(privs, p)
+		}
+
+		res.(*methods.HasPrivilegeOnEntitiesBody).Res = &types.HasPrivilegeOnEntitiesResponse{Returnval: privs}
+		return nil
+	})
+
+	tests := []struct {
+		privs  []string
+		expect []string
+	}{
+		{nil, []string{"/dc1", "/dc2"}},
+		{[]string{"System.View"}, []string{"/dc1", "/dc2"}},
+		{[]string{"System.View", "VirtualMachine.Inventory.Create"}, []string{"/dc1"}},
+	}
+
+	for _, test := range tests {
+		f.SetDatacenterPrivileges(test.privs...)
+
+		dcs, err := f.DatacenterList(ctx, "*")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assertPaths(t, dcs, test.expect)
+	}
+}
+
+func TestVirtualMachineByInstanceUuid(t *testing.T) {
+	inv := newInventory()
+	dc := inv.add(inv.root, "Datacenter", "dc")
+	vmFolder := inv.add(dc, "Folder", "vm")
+	vm1 := inv.add(vmFolder, "VirtualMachine", "vm1")
+
+	si := types.ManagedObjectReference{Type: "SearchIndex", Value: "SearchIndex"}
+
+	ctx := context.Background()
+	f := inv.finder(false, dc)
+
+	c := f.Client()
+	c.ServiceContent.SearchIndex = &si
+
+	searches := 0
+	c.RoundTripper = roundTripFunc(func(ctx context.Context, req, res soap.HasFault) error {
+		body, ok := req.(*methods.FindByUuidBody)
+		if !ok {
+			return inv.RoundTrip(ctx, req, res)
+		}
+
+		searches++
+
+		if body.Req.InstanceUuid == nil || !*body.Req.InstanceUuid || !body.Req.VmSearch {
+			t.Errorf("unexpected search: %#v", body.Req)
+		}
+
+		r := &types.FindByUuidResponse{}
+		if body.Req.Uuid == "uuid-1" {
+			r.Returnval = &vm1
+		}
+		res.(*methods.FindByUuidBody).Res = r
+		return nil
+	})
+
+	for i := 0; i < 3; i++ {
+		vm, err := f.VirtualMachineByInstanceUuid(ctx, "uuid-1")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if vm.Reference() != vm1 || vm.InventoryPath != "/dc/vm/vm1" {
+			t.Errorf("unexpected vm: %s %s", vm.Reference(), vm.InventoryPath)
+		}
+	}
+
+	if searches != 1 {
+		t.Errorf("expected 1 search, got %d", searches)
+	}
+
+	_, err := f.VirtualMachineByInstanceUuid(ctx, "enoent")
+	if _, ok := err.(*NotFoundError); !ok {
+		t.Errorf("expected NotFoundError, got %v", err)
+	}
+
+	f.Reset()
+
+	if _, err = f.VirtualMachineByInstanceUuid(ctx, "uuid-1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if searches != 3 {
+		t.Errorf("expected 3 searches, got %d", searches)
+	}
+
+	// Cached lookups are safe for concurrent use
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := f.VirtualMachineByInstanceUuid(ctx, "uuid-1"); err != nil {
+				t.Error(err)
+			}
+		}()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			f.instanceUuids.put(uuidKey{dc, "uuid-1"}, list.Element{Path: "/dc/vm/vm1", Object: object.NewVirtualMachine(c, vm1)})
+		}()
+	}
+	wg.Wait()
+}
+
+func TestForEachDatacenterInstanceUuid(t *testing.T) {
+	inv := newInventory()
+	dc1 := inv.add(inv.root, "Datacenter", "dc1")
+	vm1 := inv.add(inv.add(dc1, "Folder", "vm"), "VirtualMachine", "vm1")
+	dc2 := inv.add(inv.root, "Datacenter", "dc2")
+	vm2 := inv.add(inv.add(dc2, "Folder", "vm"), "VirtualMachine", "vm2")
+
+	si := types.ManagedObjectReference{Type: "SearchIndex", Value: "SearchIndex"}
+
+	ctx := context.Background()
+	f := inv.finder(false, types.ManagedObjectReference{})
+
+	c := f.Client()
+	c.ServiceContent.SearchIndex = &si
+
+	// Both VMs have the same instanceUuid, as can be the case for VMs restored into another datacenter
+	searches := 0
+	c.RoundTripper = roundTripFunc(func(ctx context.Context, req, res soap.HasFault) error {
+		body, ok := req.(*methods.FindByUuidBody)
+		if !ok {
+			return inv.RoundTrip(ctx, req, res)
+		}
+
+		searches++
+
+		r := &types.FindByUuidResponse{Returnval: &vm1}
+		if body.Req.Datacenter != nil && *body.Req.Datacenter == dc2 {
+			r.Returnval = &vm2
+		}
+		res.(*methods.FindByUuidBody).Res = r
+		return nil
+	})
+
+	vm, err := f.VirtualMachineByInstanceUuid(ctx, "uuid-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if vm.Reference() != vm1 {
+		t.Errorf("unexpected vm: %s", vm.Reference())
+	}
+
+	scoped := make(map[types.ManagedObjectReference]*Finder)
+
+	err = f.ForEachDatacenter(ctx, "*", func(dc *object.Datacenter, df *Finder) error {
+		scoped[dc.Reference()] = df
+
+		vm, err := df.VirtualMachineByInstanceUuid(ctx, "uuid-1")
+		if err != nil {
+			return err
+		}
+
+		expect := map[types.ManagedObjectReference]types.ManagedObjectReference{dc1: vm1, dc2: vm2}[dc.Reference()]
+		if vm.Reference() != expect {
+			t.Errorf("%s: expected %s, got %s", dc.InventoryPath, expect, vm.Reference())
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if searches != 3 {
+		t.Errorf("expected 3 searches, got %d", searches)
+	}
+
+	// The scoped Finders did not reset the cache of f, nor share it
+	if _, err = f.VirtualMachineByInstanceUuid(ctx, "uuid-1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if scoped[dc1].instanceUuids == f.instanceUuids || scoped[dc1].instanceUuids == scoped[dc2].instanceUuids {
+		t.Error("expected each scoped Finder to have its own cache")
+	}
+
+	if _, err = scoped[dc2].VirtualMachineByInstanceUuid(ctx, "uuid-1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if searches != 3 {
+		t.Errorf("expected 3 searches, got %d", searches)
+	}
+}
+
+func TestHostSystemVMotionGroups(t *testing.T) {
+	inv := newInventory()
+	dc := inv.add(inv.root, "Datacenter", "dc")
+	hostFolder := inv.add(dc, "Folder", "host")
+	cluster := inv.add(hostFolder, "ClusterComputeResource", "cluster")
+
+	vnic := func(key, ip, mask string, v6 ...types.HostIpConfigIpV6Address) types.HostVirtualNic {
+		spec := types.HostVirtualNicSpec{Ip: &types.HostIpConfig{IpAddress: ip, SubnetMask: mask}}
+		if len(v6) != 0 {
+			spec.Ip.IpV6Config = &types.HostIpConfigIpV6AddressConfiguration{IpV6Address: v6}
+		}
+		return types.HostVirtualNic{Key: key, Device: key, Spec: spec}
+	}
+
+	netConfig := func(selected []string, vnics ...types.HostVirtualNic) []types.DynamicProperty {
+		return []types.DynamicProperty{{
+			Name: "config.virtualNicManagerInfo.netConfig",
+			Val: types.ArrayOfVirtualNicManagerNetConfig{VirtualNicManagerNetConfig: []types.VirtualNicManagerNetConfig{
+				{NicType: "management", CandidateVnic: []types.HostVirtualNic{vnic("vmk0", "192.168.1.10", "255.255.255.0")}, SelectedVnic: []string{"vmk0"}},
+				{NicType: "vmotion", CandidateVnic: vnics, SelectedVnic: selected},
+			}},
+		}}
+	}
+
+	linkLocal := types.HostIpConfigIpV6Address{IpAddress: "fe80::1", PrefixLength: 64}
+	global := types.HostIpConfigIpV6Address{IpAddress: "2001:db8::11", PrefixLength: 64}
+
+	esx1 := inv.add(cluster, "HostSystem", "esx1")
+	inv.props[esx1] = netConfig([]string{"vmk1"}, vnic("vmk1", "10.0.0.11", "255.255.255.0", linkLocal))
+
+	esx2 := inv.add(cluster, "HostSystem", "esx2")
+	inv.props[esx2] = netConfig([]string{"vmk1", "vmk2"},
+		vnic("vmk1", "10.0.0.12", "255.255.255.0", global), vnic("vmk2", "10.0.1.12", "255.255.255.0"))
+
+	esx3 := inv.add(cluster, "HostSystem", "esx3")
+	inv.props[esx3] = netConfig([]string{"vmk2"},
+		vnic("vmk1", "10.0.0.13", "255.255.255.0"), vnic("vmk2", "10.0.1.13", "255.255.255.0", global))
+
+	// vMotion not enabled
+	esx4 := inv.add(cluster, "HostSystem", "esx4")
+	inv.props[esx4] = netConfig(nil, vnic("vmk1", "10.0.0.14", "255.255.255.0"))
+
+	ctx := context.Background()
+	f := inv.finder(false, dc)
+
+	hosts, err := f.HostSystemList(ctx, "cluster/*")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	groups, err := f.HostSystemVMotionGroups(ctx, hosts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := map[string]string{
+		"10.0.0.0/24":   "esx1 esx2",
+		"10.0.1.0/24":   "esx2 esx3",
+		"2001:db8::/64": "esx2 esx3",
+	}
+
+	if len(groups) != len(expect) {
+		t.Fatalf("expected %d groups, got %d", len(expect), len(groups))
+	}
+
+	for i, g := range groups {
+		if i > 0 && groups[i-1].Subnet >= g.Subnet {
+			t.Errorf("groups not sorted: %s >= %s", groups[i-1].Subnet, g.Subnet)
+		}
+
+		var names []string
+		for _, h := range g.Hosts {
+			names = append(names, h.Name())
+		}
+
+		if got := strings.Join(names, " "); got != expect[g.Subnet] {
+			t.Errorf("%s: expected %q, got %q", g.Subnet, expect[g.Subnet], got)
+		}
+	}
+
+	groups, err = f.HostSystemVMotionGroups(ctx, nil)
+	if err != nil || len(groups) != 0 {
+		t.Errorf("unexpected result: %v, %v", groups, err)
+	}
+}
+
+func TestSetExclude(t *testing.T) {
+	inv := newInventory()
+	dc := inv.add(inv.root, "Datacenter", "dc")
+	vmFolder := inv.add(dc, "Folder", "vm")
+	inv.add(vmFolder, "VirtualMachine", "web1")
+	templates := inv.add(vmFolder, "Folder", "templates")
+	inv.add(templates, "VirtualMachine", "tmpl1")
+	inv.add(templates, "VirtualMachine", "tmpl2")
+
+	ctx := context.Background()
+	f := inv.finder(false, dc)
+
+	listed := 0
+	f.Client().RoundTripper = roundTripFunc(func(ctx context.Context, req, res soap.HasFault) error {
+		if body, ok := req.(*methods.RetrievePropertiesBody); ok {
+			for _, spec := range body.Req.SpecSet {
+				for _, os := range spec.ObjectSet {
+					if os.Obj == templates {
+						listed++
+					}
+				}
+			}
+		}
+		return inv.RoundTrip(ctx, req, res)
+	})
+
+	f.SetSorted(true)
+
+	tests := []struct {
+		exclude []string
+		path    string
+		expect  []string
+		listed  bool
+	}{
+		{nil, "vm/*", []string{"/dc/vm/templates", "/dc/vm/web1"}, false},
+		{nil, "vm/templates/*", []string{"/dc/vm/templates/tmpl1", "/dc/vm/templates/tmpl2"}, true},
+		{[]string{"vm/templates"}, "vm/*", []string{"/dc/vm/web1"}, false},
+		{[]string{"vm/templates"}, "vm/templates/*", nil, false},
+		{[]string{"/dc/vm/templates"}, "vm/templates/tmpl1", nil, false},
+		{[]string{"vm/templates/*2"}, "vm/templates/*", []string{"/dc/vm/templates/tmpl1"}, true},
+		{[]string{"vm/web*"}, "vm/*", []string{"/dc/vm/templates"}, false},
+	}
+
+	for _, test := range tests {
+		f.SetExclude(test.exclude)
+		listed = 0
+
+		es, err := f.ManagedObjectList(ctx, test.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assertPaths(t, es, test.expect)
+
+		if (listed != 0) != test.listed {
+			t.Errorf("%v %s: templates folder listed %d times", test.exclude, test.path, listed)
+		}
+	}
+
+	f.SetExclude([]string{"vm/["})
+	if _, err := f.ManagedObjectList(ctx, "vm/*"); err == nil {
+		t.Error("expected error for invalid pattern")
+	}
+}
+
+func TestVirtualMachineListWithResourcePool(t *testing.T) {
+	inv := newInventory()
+	dc := inv.add(inv.root, "Datacenter", "dc")
+	vmFolder := inv.add(dc, "Folder", "vm")
+	hostFolder := inv.add(dc, "Folder", "host")
+	cluster := inv.add(hostFolder, "ClusterComputeResource", "cluster")
+	resources := inv.add(cluster, "ResourcePool", "Resources")
+	web := inv.add(resources, "ResourcePool", "web")
+
+	pools := map[string]types.ManagedObjectReference{
+		"vm1": resources,
+		"vm2": web,
+		"vm3": web,
+	}
+
+	for _, name := range []string{"vm1", "vm2", "vm3", "template"} {
+		vm := inv.add(vmFolder, "VirtualMachine", name)
+		if pool, ok := pools[name]; ok {
+			inv.props[vm] = []types.DynamicProperty{{Name: "resourcePool", Val: pool}}
+		}
+	}
+
+	ctx := context.Background()
+	f := inv.finder(false, dc)
+
+	ancestors := 0
+	f.Client().RoundTripper = roundTripFunc(func(ctx context.Context, req, res soap.HasFault) error {
+		if body, ok := req.(*methods.RetrievePropertiesBody); ok {
+			for _, spec := range body.Req.SpecSet {
+				// Ancestors of the pools, rather than of the vm folder
+				if len(spec.ObjectSet) != 0 && spec.ObjectSet[0].Obj.Type == "ResourcePool" {
+					ancestors++
+				}
+			}
+		}
+		return inv.RoundTrip(ctx, req, res)
+	})
+
+	vms, err := f.VirtualMachineListWithResourcePool(ctx, "*")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ancestors != 1 {
+		t.Errorf("expected 1 ancestors request, got %d", ancestors)
+	}
+
+	var got []string
+	for _, vm := range vms {
+		var p string
+		if vm.ResourcePool != nil {
+			p = vm.ResourcePool.InventoryPath
+		}
+		got = append(got, vm.VirtualMachine.Name()+":"+p)
+	}
+
+	assertPaths(t, got, []string{
+		"vm1:/dc/host/cluster/Resources",
+		"vm2:/dc/host/cluster/Resources/web",
+		"vm3:/dc/host/cluster/Resources/web",
+		"template:",
+	})
+}
+
+func TestVirtualMachineListByDatastore(t *testing.T) {
+	inv := newInventory()
+	dc := inv.add(inv.root, "Datacenter", "dc")
+	vmFolder := inv.add(dc, "Folder", "vm")
+	web := inv.add(vmFolder, "Folder", "web")
+	dsFolder := inv.add(dc, "Folder", "datastore")
+	ds := inv.add(dsFolder, "Datastore", "ds1")
+
+	files := func(files ...types.VirtualMachineFileLayoutExFileInfo) []types.DynamicProperty {
+		return []types.DynamicProperty{{
+			Name: "layoutEx.file",
+			Val:  types.ArrayOfVirtualMachineFileLayoutExFileInfo{VirtualMachineFileLayoutExFileInfo: files},
+		}}
+	}
+
+	disk := string(types.VirtualMachineFileLayoutExFileTypeDiskDescriptor)
+	config := string(types.VirtualMachineFileLayoutExFileTypeConfig)
+
+	vm1 := inv.add(vmFolder, "VirtualMachine", "vm1")
+	inv.props[vm1] = files(types.VirtualMachineFileLayoutExFileInfo{Name: "[ds1] vm1/vm1.vmdk", Type: disk})
+	vm2 := inv.add(vmFolder, "VirtualMachine", "vm2")
+	inv.props[vm2] = files(types.VirtualMachineFileLayoutExFileInfo{Name: "[ds1] vm2/vm2.vmx", Type: config})
+	vm3 := inv.add(web, "VirtualMachine", "vm3")
+	inv.props[vm3] = files(types.VirtualMachineFileLayoutExFileInfo{Name: "[ds1] vm3/vm3.vmdk", Type: disk})
+
+	inv.props[ds] = []types.DynamicProperty{{
+		Name: "vm",
+		Val:  types.ArrayOfManagedObjectReference{ManagedObjectReference: []types.ManagedObjectReference{vm1, vm2, vm3}},
+	}}
+
+	ctx := context.Background()
+	f := inv.finder(false, dc)
+
+	ancestors := 0
+	f.Client().RoundTripper = roundTripFunc(func(ctx context.Context, req, res soap.HasFault) error {
+		if body, ok := req.(*methods.RetrievePropertiesBody); ok {
+			for _, spec := range body.Req.SpecSet {
+				if len(spec.ObjectSet) != 0 && len(spec.ObjectSet[0].SelectSet) != 0 && spec.ObjectSet[0].Obj.Type == "VirtualMachine" {
+					ancestors++
+				}
+			}
+		}
+		return inv.RoundTrip(ctx, req, res)
+	})
+
+	tests := []struct {
+		allFiles bool
+		expect   []string
+	}{
+		{false, []string{"/dc/vm/vm1", "/dc/vm/web/vm3"}},
+		{true, []string{"/dc/vm/vm1", "/dc/vm/vm2", "/dc/vm/web/vm3"}},
+	}
+
+	for _, test := range tests {
+		ancestors = 0
+
+		vms, err := f.VirtualMachineListByDatastore(ctx, object.NewDatastore(f.Client(), ds), test.allFiles)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if ancestors != 1 {
+			t.Errorf("expected 1 ancestors request, got %d", ancestors)
+		}
+
+		assertPaths(t, vms, test.expect)
+	}
+}
+
+func TestVirtualMachineSummary(t *testing.T) {
+	inv := newInventory()
+	dc := inv.add(inv.root, "Datacenter", "dc")
+	vmFolder := inv.add(dc, "Folder", "vm")
+	inv.add(vmFolder, "Folder", "empty")
+
+	state := func(s types.VirtualMachinePowerState, template bool) []types.DynamicProperty {
+		return []types.DynamicProperty{
+			{Name: "runtime.powerState", Val: s},
+			{Name: "config.template", Val: template},
+		}
+	}
+
+	vm1 := inv.add(vmFolder, "VirtualMachine", "vm1")
+	inv.props[vm1] = state(types.VirtualMachinePowerStatePoweredOn, false)
+	vm2 := inv.add(vmFolder, "VirtualMachine", "vm2")
+	inv.props[vm2] = state(types.VirtualMachinePowerStatePoweredOff, false)
+	vm3 := inv.add(vmFolder, "VirtualMachine", "vm3")
+	inv.props[vm3] = state(types.VirtualMachinePowerStatePoweredOff, true)
+
+	ctx := context.Background()
+	f := inv.finder(false, dc)
+
+	tests := []struct {
+		path   string
+		expect VMStats
+	}{
+		{"*", VMStats{Total: 3, PoweredOn: 1, PoweredOff: 1, Templates: 1}},
+		{"empty/*", VMStats{}},
+	}
+
+	for _, test := range tests {
+		stats, err := f.VirtualMachineSummary(ctx, test.path)
+		if err != nil {
+			t.Fatalf("%s: %s", test.path, err)
+		}
+
+		if stats != test.expect {
+			t.Errorf("%s: expected %+v, got %+v", test.path, test.expect, stats)
+		}
+	}
+}
+
+func TestManagedObjectListContext(t *testing.T) {
+	inv := newInventory()
+	dc := inv.add(inv.root, "Datacenter", "dc")
+	vmFolder := inv.add(dc, "Folder", "vm")
+	web := inv.add(vmFolder, "Folder", "web")
+	vm1 := inv.add(web, "VirtualMachine", "vm1")
+	vm2 := inv.add(web, "VirtualMachine", "vm2")
+	inv.add(vmFolder, "VirtualMachine", "vm3")
+
+	inv.props[web] = []types.DynamicProperty{{
+		Name: "childEntity",
+		Val:  types.ArrayOfManagedObjectReference{ManagedObjectReference: []types.ManagedObjectReference{vm1, vm2}},
+	}}
+
+	ctx := context.Background()
+	f := inv.finder(false, dc)
+
+	listed := 0
+	f.Client().RoundTripper = roundTripFunc(func(ctx context.Context, req, res soap.HasFault) error {
+		if body, ok := req.(*methods.RetrievePropertiesBody); ok {
+			for _, spec := range body.Req.SpecSet {
+				if len(spec.ObjectSet) != 0 && spec.ObjectSet[0].Obj == web {
+					listed++
+				}
+			}
+		}
+		return inv.RoundTrip(ctx, req, res)
+	})
+
+	ecs, err := f.ManagedObjectListContext(ctx, "vm")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if listed != 0 {
+		t.Errorf("expected children of web to be counted without listing, got %d requests", listed)
+	}
+
+	children := make(map[string]int)
+	for _, ec := range ecs {
+		children[ec.Name] = ec.Children
+		if ec.IsContainer != (ec.Name == "web") {
+			t.Errorf("%s: unexpected IsContainer=%t", ec.Name, ec.IsContainer)
+		}
+	}
+
+	expect := map[string]int{"web": 2, "vm3": 0}
+	if !reflect.DeepEqual(children, expect) {
+		t.Errorf("expected %v, got %v", expect, children)
+	}
+}
+
+func TestVirtualMachineListPaths(t *testing.T) {
+	inv := newInventory()
+	dc := inv.add(inv.root, "Datacenter", "dc")
+	vmFolder := inv.add(dc, "Folder", "vm")
+	inv.add(vmFolder, "VirtualMachine", "web-prod")
+	inv.add(vmFolder, "VirtualMachine", "db")
+	inv.add(vmFolder, "VirtualMachine", "web-dev")
+
+	ctx := context.Background()
+	f := inv.finder(false, dc)
+
+	vms, err := f.VirtualMachineList(ctx, "web*", "db", "web-prod")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertPaths(t, vms, []string{"/dc/vm/web-prod", "/dc/vm/web-dev", "/dc/vm/db"})
+
+	vms, err = f.SetSorted(true).VirtualMachineList(ctx, "web*", "db", "web-prod")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertPaths(t, vms, []string{"/dc/vm/db", "/dc/vm/web-dev", "/dc/vm/web-prod"})
+
+	_, err = f.VirtualMachineList(ctx, "app*", "mail")
+	if err == nil || err.Error() != "vm 'app* mail' not found" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,373 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package find
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"strings"
+
+	"github.com/vmware/govmomi/list"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// InventoryNode is a managed entity as returned by ManagedObjectNodes and read from a file by FromInventoryFile.
+// Its JSON encoding is stable.  In a file, the parent of each node is the node with the parent directory of its Path, and the root folder has Path "/".
+type InventoryNode struct {
+	Name string
+	Path string
+	Type string
+	Ref  types.ManagedObjectReference
+}
+
+// ToInventoryNode converts the given element to an InventoryNode.
+// A file of the nodes visited by Walk from the root folder can be read by FromInventoryFile.
+func ToInventoryNode(e list.Element) InventoryNode {
+	ref := e.Object.Reference()
+
+	return InventoryNode{
+		Name: path.Base(e.Path),
+		Path: e.Path,
+		Type: ref.Type,
+		Ref:  ref,
+	}
+}
+
+// FromInventoryFile returns a Finder that resolves paths against the inventory saved in the given file,
+// a JSON array of InventoryNode, instead of a live vCenter or ESX.  Only the inventory hierarchy and the
+// name of each entity are available; properties other than those used for traversal are not set.
+// The inventory is the list.Source of the Finder, and methods of the objects returned,
+// which would invoke vCenter or ESX, fail.  This is intended for testing and offline inventory browsing.
+func FromInventoryFile(name string) (*Finder, error) {
+	b, err := ioutil.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var nodes []InventoryNode
+
+	if err = json.Unmarshal(b, &nodes); err != nil {
+		return nil, fmt.Errorf("%s: %s", name, err)
+	}
+
+	inv, err := newOfflineInventory(nodes)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", name, err)
+	}
+
+	c := &vim25.Client{
+		RoundTripper: unsupported{},
+		ServiceContent: types.ServiceContent{
+			RootFolder:        inv.root,
+			PropertyCollector: types.ManagedObjectReference{Type: "PropertyCollector", Value: "propertyCollector"},
+		},
+	}
+
+	f := NewFinder(c, false)
+	f.recurser.Source = inv
+
+	return f, nil
+}
+
+// unsupported is the soap.RoundTripper of a Finder created by FromInventoryFile, which has no connection.
+type unsupported struct{}
+
+func (unsupported) RoundTrip(ctx context.Context, req, res soap.HasFault) error {
+	return fmt.Errorf("%T is not supported by an inventory file", req)
+}
+
+// offlineInventory is a list.Source that answers RetrieveProperties requests from a set of InventoryNode.
+type offlineInventory struct {
+	root     types.ManagedObjectReference
+	nodes    map[types.ManagedObjectReference]InventoryNode
+	parents  map[types.ManagedObjectReference]types.ManagedObjectReference
+	children map[types.ManagedObjectReference][]types.ManagedObjectReference
+}
+
+func newOfflineInventory(nodes []InventoryNode) (*offlineInventory, error) {
+	i := &offlineInventory{
+		nodes:    make(map[types.ManagedObjectReference]InventoryNode),
+		parents:  make(map[types.ManagedObjectReference]types.ManagedObjectReference),
+		children: make(map[types.ManagedObjectReference][]types.ManagedObjectReference),
+	}
+
+	paths := make(map[string]types.ManagedObjectReference)
+
+	for n, node := range nodes {
+		if node.Type == "" {
+			node.Type = node.Ref.Type
+		}
+		if node.Type == "" || !strings.HasPrefix(node.Path, "/") {
+			return nil, fmt.Errorf("invalid inventory node %d: %q", n, node.Path)
+		}
+
+		node.Path = path.Clean(node.Path)
+		if node.Name == "" {
+			node.Name = path.Base(node.Path)
+		}
+
+		if node.Ref.Value == "" {
+			node.Ref = types.ManagedObjectReference{Type: node.Type, Value: fmt.Sprintf("%s-%d", node.Type, n)}
+		}
+		node.Ref.Type = node.Type
+
+		if _, ok := paths[node.Path]; ok {
+			return nil, fmt.Errorf("duplicate inventory path %q", node.Path)
+		}
+
+		paths[node.Path] = node.Ref
+		i.nodes[node.Ref] = node
+	}
+
+	root, ok := paths["/"]
+	if !ok {
+		return nil, fmt.Errorf("inventory has no root folder")
+	}
+	i.root = root
+
+	// Link nodes in the input order, such that children are listed in the same order
+	for _, node := range nodes {
+		ref := paths[path.Clean(node.Path)]
+		if ref == root {
+			continue
+		}
+
+		parent, ok := paths[path.Dir(i.nodes[ref].Path)]
+		if !ok {
+			return nil, fmt.Errorf("inventory path %q has no parent", i.nodes[ref].Path)
+		}
+
+		i.link(parent, ref)
+	}
+
+	return i, nil
+}
+
+// link makes ref the last child of parent.
+func (i *offlineInventory) link(parent, ref types.ManagedObjectReference) {
+	i.parents[ref] = parent
+	i.children[parent] = append(i.children[parent], ref)
+}
+
+// isA returns true if kind is the given managed object type or one of its subtypes.
+func isA(kind, base string) bool {
+	if kind == base || base == "ManagedEntity" {
+		return true
+	}
+
+	switch base {
+	case "Folder":
+		return kind == "StoragePod"
+	case "ComputeResource":
+		return kind == "ClusterComputeResource"
+	case "ResourcePool":
+		return kind == "VirtualApp"
+	case "Network":
+		return kind == "OpaqueNetwork" || kind == "DistributedVirtualPortgroup"
+	case "DistributedVirtualSwitch":
+		return kind == "VmwareDistributedVirtualSwitch"
+	}
+
+	return false
+}
+
+// refs returns the objects referenced by the given property of ref.
+func (i *offlineInventory) refs(ref types.ManagedObjectReference, property string) []types.ManagedObjectReference {
+	var kinds []string
+
+	switch property {
+	case "parent":
+		if parent, ok := i.parents[ref]; ok {
+			return []types.ManagedObjectReference{parent}
+		}
+		return nil
+	case "childEntity":
+		return i.children[ref]
+	case "vmFolder", "hostFolder", "datastoreFolder", "networkFolder":
+		if ref.Type != "Datacenter" {
+			return nil
+		}
+		name := strings.TrimSuffix(property, "Folder")
+		for _, child := range i.children[ref] {
+			if i.nodes[child].Name == name {
+				return []types.ManagedObjectReference{child}
+			}
+		}
+		return nil
+	case "host":
+		kinds = []string{"HostSystem"}
+	case "resourcePool":
+		kinds = []string{"ResourcePool", "VirtualApp"}
+	case "vm":
+		kinds = []string{"VirtualMachine"}
+	default:
+		return nil
+	}
+
+	var refs []types.ManagedObjectReference
+	for _, child := range i.children[ref] {
+		for _, kind := range kinds {
+			if child.Type == kind {
+				refs = append(refs, child)
+			}
+		}
+	}
+
+	return refs
+}
+
+// property returns the value of the given property of ref, if supported.
+func (i *offlineInventory) property(ref types.ManagedObjectReference, name string) (types.AnyType, bool) {
+	switch name {
+	case "name":
+		return i.nodes[ref].Name, true
+	case "parent":
+		if parent, ok := i.parents[ref]; ok {
+			return parent, true
+		}
+	case "vmFolder", "hostFolder", "datastoreFolder", "networkFolder":
+		if refs := i.refs(ref, name); len(refs) == 1 {
+			return refs[0], true
+		}
+	case "childEntity":
+		if isA(ref.Type, "Folder") {
+			return types.ArrayOfManagedObjectReference{ManagedObjectReference: i.refs(ref, name)}, true
+		}
+	case "host":
+		if isA(ref.Type, "ComputeResource") {
+			return types.ArrayOfManagedObjectReference{ManagedObjectReference: i.refs(ref, name)}, true
+		}
+	case "resourcePool":
+		refs := i.refs(ref, name)
+		switch {
+		case isA(ref.Type, "ComputeResource") && len(refs) != 0:
+			return refs[0], true
+		case isA(ref.Type, "ResourcePool"):
+			return types.ArrayOfManagedObjectReference{ManagedObjectReference: refs}, true
+		}
+	case "vm":
+		if isA(ref.Type, "ResourcePool") {
+			return types.ArrayOfManagedObjectReference{ManagedObjectReference: i.refs(ref, name)}, true
+		}
+	}
+
+	return nil, false
+}
+
+// content returns the properties of ref requested by the first matching PropertySpec, or false if none match.
+func (i *offlineInventory) content(ref types.ManagedObjectReference, specs []types.PropertySpec) (types.ObjectContent, bool) {
+	oc := types.ObjectContent{Obj: ref}
+	var match bool
+
+	for _, spec := range specs {
+		if !isA(ref.Type, spec.Type) {
+			continue
+		}
+		match = true
+
+		names := spec.PathSet
+		if spec.All != nil && *spec.All {
+			names = []string{"name", "parent", "childEntity", "vmFolder", "hostFolder", "datastoreFolder", "networkFolder", "host", "resourcePool", "vm"}
+		}
+
+		for _, name := range names {
+			if val, ok := i.property(ref, name); ok {
+				oc.PropSet = append(oc.PropSet, types.DynamicProperty{Name: name, Val: val})
+			}
+		}
+	}
+
+	return oc, match
+}
+
+// traverse appends the objects reached from ref by following the given selection specs to objs.
+func (i *offlineInventory) traverse(ref types.ManagedObjectReference, selectSet []types.BaseSelectionSpec,
+	named map[string]*types.TraversalSpec, objs *[]types.ManagedObjectReference) {
+	for _, s := range selectSet {
+		ts, ok := s.(*types.TraversalSpec)
+		if !ok {
+			if ts, ok = named[s.GetSelectionSpec().Name]; !ok {
+				continue
+			}
+		}
+
+		if !isA(ref.Type, ts.Type) {
+			continue
+		}
+
+		for _, child := range i.refs(ref, ts.Path) {
+			if ts.Skip == nil || !*ts.Skip {
+				*objs = append(*objs, child)
+			}
+			i.traverse(child, ts.SelectSet, named, objs)
+		}
+	}
+}
+
+// traversalSpecs adds the named TraversalSpecs in selectSet, including nested specs, to named.
+func traversalSpecs(selectSet []types.BaseSelectionSpec, named map[string]*types.TraversalSpec) {
+	for _, s := range selectSet {
+		if ts, ok := s.(*types.TraversalSpec); ok {
+			if ts.Name != "" {
+				named[ts.Name] = ts
+			}
+			traversalSpecs(ts.SelectSet, named)
+		}
+	}
+}
+
+func (i *offlineInventory) RetrieveProperties(ctx context.Context, req types.RetrieveProperties) (*types.RetrievePropertiesResponse, error) {
+	var objs []types.ObjectContent
+
+	for _, spec := range req.SpecSet {
+		var refs []types.ManagedObjectReference
+
+		for _, os := range spec.ObjectSet {
+			if _, ok := i.nodes[os.Obj]; !ok {
+				continue
+			}
+
+			if os.Skip == nil || !*os.Skip {
+				refs = append(refs, os.Obj)
+			}
+
+			named := make(map[string]*types.TraversalSpec)
+			traversalSpecs(os.SelectSet, named)
+			i.traverse(os.Obj, os.SelectSet, named, &refs)
+		}
+
+		seen := make(map[types.ManagedObjectReference]bool)
+
+		for _, ref := range refs {
+			if seen[ref] {
+				continue
+			}
+			seen[ref] = true
+
+			if oc, ok := i.content(ref, spec.PropSet); ok {
+				objs = append(objs, oc)
+			}
+		}
+	}
+
+	return &types.RetrievePropertiesResponse{Returnval: objs}, nil
+}
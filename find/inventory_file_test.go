@@ -0,0 +1,229 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package find
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+const inventoryFile = `[
+  {"Path": "/", "Type": "Folder"},
+  {"Path": "/dc", "Type": "Datacenter"},
+  {"Path": "/dc/vm", "Type": "Folder"},
+  {"Path": "/dc/vm/web", "Type": "Folder"},
+  {"Path": "/dc/vm/web/web01", "Type": "VirtualMachine", "Ref": {"Type": "VirtualMachine", "Value": "vm-42"}},
+  {"Path": "/dc/vm/db01", "Type": "VirtualMachine"},
+  {"Path": "/dc/host", "Type": "Folder"},
+  {"Path": "/dc/host/cluster", "Type": "ClusterComputeResource"},
+  {"Path": "/dc/host/cluster/Resources", "Type": "ResourcePool"},
+  {"Path": "/dc/host/cluster/esx1", "Type": "HostSystem"},
+  {"Path": "/dc/host/cluster/esx2", "Type": "HostSystem"},
+  {"Path": "/dc/datastore", "Type": "Folder"},
+  {"Path": "/dc/datastore/pod", "Type": "StoragePod"},
+  {"Path": "/dc/datastore/pod/ds1", "Type": "Datastore"},
+  {"Path": "/dc/network", "Type": "Folder"},
+  {"Path": "/dc/network/VM Network", "Type": "Network"}
+]`
+
+func writeInventoryFile(t *testing.T, content string) string {
+	f, err := ioutil.TempFile("", "inventory")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = f.WriteString(content); err != nil {
+		t.Fatal(err)
+	}
+
+	_ = f.Close()
+
+	return f.Name()
+}
+
+func TestFromInventoryFile(t *testing.T) {
+	name := writeInventoryFile(t, inventoryFile)
+	defer os.Remove(name)
+
+	f, err := FromInventoryFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+
+	dc, err := f.DefaultDatacenter(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.SetDatacenter(dc)
+
+	vms, err := f.VirtualMachineList(ctx, "web/*")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(vms) != 1 || vms[0].InventoryPath != "/dc/vm/web/web01" || vms[0].Reference().Value != "vm-42" {
+		t.Errorf("unexpected vms: %v", vms)
+	}
+
+	vms, err = f.VirtualMachineList(ctx, "/dc/vm/db*")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(vms) != 1 || vms[0].InventoryPath != "/dc/vm/db01" {
+		t.Errorf("unexpected vms: %v", vms)
+	}
+
+	hosts, err := f.HostSystemList(ctx, "cluster")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(hosts) != 2 || hosts[1].InventoryPath != "/dc/host/cluster/esx2" {
+		t.Errorf("unexpected hosts: %v", hosts)
+	}
+
+	pool, err := f.DefaultResourcePool(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if pool.InventoryPath != "/dc/host/cluster/Resources" {
+		t.Errorf("unexpected pool: %s", pool.InventoryPath)
+	}
+
+	ds, err := f.Datastore(ctx, "pod/ds1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := f.InventoryPath(ctx, ds)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p != "/dc/datastore/pod/ds1" {
+		t.Errorf("unexpected path: %s", p)
+	}
+
+	if _, err = f.DefaultNetwork(ctx); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestFromInventoryFileInvalid(t *testing.T) {
+	tests := []string{
+		`{}`,
+		`[{"Path": "/dc", "Type": "Datacenter"}]`,
+		`[{"Path": "/", "Type": "Folder"}, {"Path": "/dc/vm", "Type": "Folder"}]`,
+		`[{"Path": "/", "Type": "Folder"}, {"Path": "/dc", "Type": "Datacenter"}, {"Path": "/dc", "Type": "Datacenter"}]`,
+		`[{"Path": "/", "Type": "Folder"}, {"Path": "dc", "Type": "Datacenter"}]`,
+	}
+
+	for _, test := range tests {
+		name := writeInventoryFile(t, test)
+
+		if _, err := FromInventoryFile(name); err == nil {
+			t.Errorf("%s: expected error", test)
+		}
+
+		_ = os.Remove(name)
+	}
+}
+
+func TestManagedObjectNodes(t *testing.T) {
+	inv := newInventory()
+	dc := inv.add(inv.root, "Datacenter", "dc")
+	vmFolder := inv.add(dc, "Folder", "vm")
+	vm := inv.add(vmFolder, "VirtualMachine", "vm1")
+
+	ctx := context.Background()
+	f := inv.finder(false, dc)
+
+	nodes, err := f.ManagedObjectNodes(ctx, "/dc/vm/*")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := json.Marshal(nodes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := `[{"Name":"vm1","Path":"/dc/vm/vm1","Type":"VirtualMachine","Ref":{"Type":"VirtualMachine","Value":"` + vm.Value + `"}}]`
+	if string(b) != expect {
+		t.Errorf("expected %s, got %s", expect, b)
+	}
+}
+
+func TestResourcePoolListWithDepth(t *testing.T) {
+	name := writeInventoryFile(t, `[
+  {"Path": "/", "Type": "Folder"},
+  {"Path": "/dc", "Type": "Datacenter"},
+  {"Path": "/dc/host", "Type": "Folder"},
+  {"Path": "/dc/host/cluster", "Type": "ClusterComputeResource"},
+  {"Path": "/dc/host/cluster/Resources", "Type": "ResourcePool"},
+  {"Path": "/dc/host/cluster/Resources/a", "Type": "ResourcePool"},
+  {"Path": "/dc/host/cluster/Resources/a/a1", "Type": "ResourcePool"},
+  {"Path": "/dc/host/cluster/Resources/a/vapp", "Type": "VirtualApp"},
+  {"Path": "/dc/host/cluster/Resources/b", "Type": "ResourcePool"}
+]`)
+	defer os.Remove(name)
+
+	f, err := FromInventoryFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+
+	root, err := f.ResourcePool(ctx, "/dc/host/cluster/Resources")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pools, err := f.ResourcePoolListWithDepth(ctx, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := []struct {
+		path  string
+		depth int
+	}{
+		{"/dc/host/cluster/Resources", 0},
+		{"/dc/host/cluster/Resources/a", 1},
+		{"/dc/host/cluster/Resources/a/a1", 2},
+		{"/dc/host/cluster/Resources/a/vapp", 2},
+		{"/dc/host/cluster/Resources/b", 1},
+	}
+
+	if len(pools) != len(expect) {
+		t.Fatalf("expected %d pools, got %d", len(expect), len(pools))
+	}
+
+	for i, p := range pools {
+		if p.Pool.InventoryPath != expect[i].path || p.Depth != expect[i].depth {
+			t.Errorf("%d: expected %s (%d), got %s (%d)", i, expect[i].path, expect[i].depth, p.Pool.InventoryPath, p.Depth)
+		}
+	}
+}
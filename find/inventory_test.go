@@ -0,0 +1,185 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package find
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"reflect"
+	"testing"
+
+	"github.com/vmware/govmomi/list"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// inventory is a soap.RoundTripper that answers RetrieveProperties requests from an offlineInventory,
+// as used by FromInventoryFile, along with any additional properties.
+type inventory struct {
+	*offlineInventory
+
+	hosts map[types.ManagedObjectReference][]types.ManagedObjectReference
+	props map[types.ManagedObjectReference][]types.DynamicProperty
+	ids   int
+}
+
+func newInventory() *inventory {
+	i := &inventory{
+		offlineInventory: &offlineInventory{
+			nodes:    make(map[types.ManagedObjectReference]InventoryNode),
+			parents:  make(map[types.ManagedObjectReference]types.ManagedObjectReference),
+			children: make(map[types.ManagedObjectReference][]types.ManagedObjectReference),
+		},
+		hosts: make(map[types.ManagedObjectReference][]types.ManagedObjectReference),
+		props: make(map[types.ManagedObjectReference][]types.DynamicProperty),
+	}
+
+	i.root = i.add(types.ManagedObjectReference{}, "Folder", "Datacenters")
+
+	return i
+}
+
+// add creates an entity of the given kind and name below parent.
+func (i *inventory) add(parent types.ManagedObjectReference, kind, name string) types.ManagedObjectReference {
+	i.ids++
+	ref := types.ManagedObjectReference{Type: kind, Value: fmt.Sprintf("%s-%d", kind, i.ids)}
+
+	node := InventoryNode{Name: name, Path: "/", Type: kind, Ref: ref}
+	if parent.Value != "" {
+		node.Path = path.Join(i.nodes[parent].Path, name)
+		i.link(parent, ref)
+	}
+	i.nodes[ref] = node
+
+	return ref
+}
+
+func (i *inventory) RoundTrip(ctx context.Context, req, res soap.HasFault) error {
+	body, ok := req.(*methods.RetrievePropertiesBody)
+	if !ok {
+		return fmt.Errorf("unsupported request: %T", req)
+	}
+
+	r, err := i.RetrieveProperties(ctx, *body.Req)
+	if err != nil {
+		return err
+	}
+
+	// Objects outside of the inventory, such as the SessionManager, have only additional properties
+	for _, spec := range body.Req.SpecSet {
+		for _, os := range spec.ObjectSet {
+			if _, ok := i.nodes[os.Obj]; !ok {
+				r.Returnval = append(r.Returnval, types.ObjectContent{Obj: os.Obj})
+			}
+		}
+	}
+
+	for n := range r.Returnval {
+		oc := &r.Returnval[n]
+
+		if hosts, ok := i.hosts[oc.Obj]; ok {
+			for k := range oc.PropSet {
+				if oc.PropSet[k].Name == "host" {
+					oc.PropSet[k].Val = types.ArrayOfManagedObjectReference{ManagedObjectReference: hosts}
+				}
+			}
+		}
+
+		// Additional properties are returned whether requested or not
+		oc.PropSet = append(oc.PropSet, i.props[oc.Obj]...)
+	}
+
+	res.(*methods.RetrievePropertiesBody).Res = r
+
+	return nil
+}
+
+// roundTripFunc adapts a function to soap.RoundTripper, for example to modify the inventory between requests.
+type roundTripFunc func(ctx context.Context, req, res soap.HasFault) error
+
+func (f roundTripFunc) RoundTrip(ctx context.Context, req, res soap.HasFault) error {
+	return f(ctx, req, res)
+}
+
+// finder returns a Finder backed by the inventory, with dc as its datacenter unless dc is the zero value.
+// The datacenter folders are the children of dc named "vm", "host", "datastore" and "network".
+func (i *inventory) finder(all bool, dc types.ManagedObjectReference) *Finder {
+	c := &vim25.Client{
+		RoundTripper: i,
+		ServiceContent: types.ServiceContent{
+			RootFolder:        i.root,
+			PropertyCollector: types.ManagedObjectReference{Type: "PropertyCollector", Value: "propertyCollector"},
+		},
+	}
+
+	f := NewFinder(c, all)
+
+	if dc.Value != "" {
+		d := object.NewDatacenter(c, dc)
+		d.InventoryPath = "/" + i.nodes[dc].Name
+		f.SetDatacenter(d)
+	}
+
+	return f
+}
+
+// pathsOf returns the path of each element of objs, a slice of list.Element, of strings,
+// or of objects with an InventoryPath field, such as []*object.VirtualMachine.
+func pathsOf(objs interface{}) []string {
+	v := reflect.ValueOf(objs)
+	paths := make([]string, v.Len())
+
+	for i := range paths {
+		e := v.Index(i)
+		if e.Kind() == reflect.Interface {
+			e = e.Elem()
+		}
+		e = reflect.Indirect(e)
+
+		switch o := e.Interface().(type) {
+		case list.Element:
+			paths[i] = o.Path
+		case string:
+			paths[i] = o
+		default:
+			paths[i] = e.FieldByName("InventoryPath").String()
+		}
+	}
+
+	return paths
+}
+
+// assertPaths reports an error unless the paths of got, as returned by pathsOf, are expect in the same order.
+func assertPaths(t *testing.T, got interface{}, expect []string) {
+	paths := pathsOf(got)
+
+	if len(paths) != len(expect) {
+		t.Errorf("expected %v, got %v", expect, paths)
+		return
+	}
+
+	for i := range paths {
+		if paths[i] != expect[i] {
+			t.Errorf("expected %v, got %v", expect, paths)
+			return
+		}
+	}
+}
@@ -0,0 +1,99 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package find
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// SetRetry configures the Finder to retry inventory traversal up to max times when it fails with a
+// transient error, such as an overloaded vCenter.  The delay between attempts starts at base and
+// doubles after each attempt.  A max of 0 disables retries, which is the default.
+func (f *Finder) SetRetry(max int, base time.Duration) *Finder {
+	f.retryMax = max
+	f.retryBase = base
+	return f
+}
+
+// isRetryable returns true if the given error is likely transient, such as a fault or HTTP status
+// indicating that vCenter is overloaded or temporarily unable to reach a host.
+// Other faults, such as NotAuthenticated or ManagedObjectNotFound, are not retryable.
+func isRetryable(err error) bool {
+	if soap.IsSoapFault(err) {
+		switch soap.ToSoapFault(err).VimFault().(type) {
+		case types.SystemError, types.HostCommunication:
+			return true
+		}
+
+		return false
+	}
+
+	if soap.IsVimFault(err) {
+		switch soap.ToVimFault(err).(type) {
+		case *types.SystemError, *types.HostCommunication:
+			return true
+		}
+
+		return false
+	}
+
+	if soap.IsRegularError(err) {
+		err = soap.ToRegularError(err)
+	}
+
+	if soap.IsStatusError(err) {
+		switch soap.ToStatusCode(err) {
+		case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+
+		return false
+	}
+
+	if nerr, ok := err.(net.Error); ok {
+		return nerr.Temporary() || nerr.Timeout()
+	}
+
+	return false
+}
+
+// retry calls fn until it succeeds, fails with an error that is not retryable,
+// or the number of attempts configured via SetRetry is exhausted.
+func (f *Finder) retry(ctx context.Context, fn func() error) error {
+	delay := f.retryBase
+
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= f.retryMax || !isRetryable(err) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+
+		delay *= 2
+	}
+}
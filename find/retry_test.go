@@ -0,0 +1,150 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package find
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// statusError returns the error of a request to a server responding with the given HTTP status code.
+func statusError(code int) error {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(code)
+	}))
+	defer s.Close()
+
+	u, _ := url.Parse(s.URL)
+	c := soap.NewClient(u, true)
+
+	req := &methods.RetrievePropertiesBody{Req: &types.RetrieveProperties{}}
+	return c.RoundTrip(context.Background(), req, &methods.RetrievePropertiesBody{})
+}
+
+func TestIsRetryable(t *testing.T) {
+	fault := func(code string, detail types.AnyType) error {
+		f := &soap.Fault{Code: code}
+		f.Detail.Fault = detail
+		return soap.WrapSoapFault(f)
+	}
+
+	tests := []struct {
+		err       error
+		retryable bool
+	}{
+		{fault("ServerFaultCode", types.SystemError{}), true},
+		{fault("ServerFaultCode", types.HostCommunication{}), true},
+		{fault("ServerFaultCode", nil), false},
+		{fault("ServerFaultCode", types.InvalidArgument{}), false},
+		{fault("ServerFaultCode", types.NotAuthenticated{}), false},
+		{fault("ServerFaultCode", types.ManagedObjectNotFound{}), false},
+		{soap.WrapVimFault(&types.SystemError{}), true},
+		{soap.WrapVimFault(&types.ManagedObjectNotFound{}), false},
+		{statusError(http.StatusServiceUnavailable), true},
+		{statusError(http.StatusTooManyRequests), true},
+		{statusError(http.StatusNotFound), false},
+		{soap.WrapRegularError(statusError(http.StatusGatewayTimeout)), true},
+		{errors.New("503 Service Unavailable"), false},
+	}
+
+	for i, test := range tests {
+		if isRetryable(test.err) != test.retryable {
+			t.Errorf("%d: isRetryable(%s) != %t", i, test.err, test.retryable)
+		}
+	}
+}
+
+func TestRetry(t *testing.T) {
+	ctx := context.Background()
+	f := (&Finder{}).SetRetry(2, 0)
+
+	unavailable := statusError(http.StatusServiceUnavailable)
+	notFound := statusError(http.StatusNotFound)
+
+	calls := 0
+	err := f.retry(ctx, func() error {
+		calls++
+		return unavailable
+	})
+	if err == nil || calls != 3 {
+		t.Errorf("expected 3 calls with error, got %d: %v", calls, err)
+	}
+
+	calls = 0
+	err = f.retry(ctx, func() error {
+		calls++
+		return notFound
+	})
+	if err == nil || calls != 1 {
+		t.Errorf("expected 1 call with error, got %d: %v", calls, err)
+	}
+}
+
+func TestRetrieveRetry(t *testing.T) {
+	inv := newInventory()
+	dc := inv.add(inv.root, "Datacenter", "dc")
+	inv.add(dc, "Folder", "vm")
+
+	ctx := context.Background()
+	f := inv.finder(false, dc).SetRetry(2, 0)
+
+	unavailable := statusError(http.StatusServiceUnavailable)
+
+	calls := 0
+	f.Client().RoundTripper = roundTripFunc(func(ctx context.Context, req, res soap.HasFault) error {
+		calls++
+		if calls == 1 {
+			return unavailable
+		}
+		return inv.RoundTrip(ctx, req, res)
+	})
+
+	states, err := f.DatacenterListWithFolders(ctx, "*")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(states) != 1 || !states[0].VmFolder {
+		t.Errorf("unexpected states: %#v", states)
+	}
+
+	calls = 0
+	f.Client().RoundTripper = roundTripFunc(func(ctx context.Context, req, res soap.HasFault) error {
+		calls++
+		if _, ok := req.(*methods.RetrievePropertiesBody); ok && calls > 1 {
+			return unavailable
+		}
+		return inv.RoundTrip(ctx, req, res)
+	})
+
+	if _, err = f.DatacenterListWithFolders(ctx, "*"); err == nil {
+		t.Error("expected error")
+	}
+
+	// 1 traversal request, followed by 3 attempts for the folders of the datacenter
+	if calls != 4 {
+		t.Errorf("expected 4 calls, got %d", calls)
+	}
+}
@@ -0,0 +1,90 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package find
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"reflect"
+	"strings"
+
+	"github.com/vmware/govmomi/object"
+)
+
+// SetRoot scopes the Finder to the inventory below ref, such as a tenant's folder.
+// Absolute paths are matched from ref rather than the root folder, as are relative paths when the
+// folder they are relative to contains ref.  The InventoryPath of results is rendered relative to
+// displayPrefix in place of the path to ref.  Lookups that resolve to an object outside of ref
+// return an error.  Passing a nil ref restores the root folder.
+func (f *Finder) SetRoot(ref object.Reference, displayPrefix string) *Finder {
+	f.rootPath = ""
+
+	if isNil(ref) {
+		f.root = nil
+		f.rootPrefix = ""
+		return f
+	}
+
+	f.root = ref
+	f.rootPrefix = path.Join("/", displayPrefix)
+	return f
+}
+
+// isNil returns true if ref is nil, or a nil pointer such as a (*object.Folder)(nil).
+func isNil(ref object.Reference) bool {
+	if ref == nil {
+		return true
+	}
+
+	v := reflect.ValueOf(ref)
+	return v.Kind() == reflect.Ptr && v.IsNil()
+}
+
+// scopedRootPath returns the inventory path of the configured root.
+func (f *Finder) scopedRootPath(ctx context.Context) (string, error) {
+	if f.rootPath != "" {
+		return f.rootPath, nil
+	}
+
+	p, err := f.inventoryPath(ctx, f.root.Reference())
+	if err != nil {
+		return "", err
+	}
+
+	f.rootPath = p
+
+	return p, nil
+}
+
+// isWithin returns true if p is base or one of its descendants.
+func isWithin(p, base string) bool {
+	return base == "/" || p == base || strings.HasPrefix(p, base+"/")
+}
+
+// scopePath translates p from an inventory path below the configured root to its display path.
+func (f *Finder) scopePath(p, rootPath string) (string, error) {
+	if !isWithin(p, rootPath) {
+		return "", fmt.Errorf("%s is outside of the inventory root", p)
+	}
+
+	if rootPath == "/" {
+		return path.Join(f.rootPrefix, p), nil
+	}
+
+	return path.Join(f.rootPrefix, strings.TrimPrefix(p, rootPath)), nil
+}
@@ -0,0 +1,68 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package find
+
+import (
+	"context"
+
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// TagAssociations resolves the objects a vSphere tag is attached to.
+// Tag associations are only available via the vCenter REST API, as implemented by the Client of
+// package github.com/vmware/govmomi/vapi/tags.
+type TagAssociations interface {
+	AttachedObjects(ctx context.Context, category string, tag string) ([]types.ManagedObjectReference, error)
+}
+
+// VirtualMachineListByTag returns the VMs attached to the given tag within the given category,
+// as resolved by tags, such as a logged in tags.Client, with the InventoryPath field set.
+// The paths of all VMs are resolved with a single property collector request.
+func (f *Finder) VirtualMachineListByTag(ctx context.Context, tags TagAssociations, category string, tag string) ([]*object.VirtualMachine, error) {
+	refs, err := tags.AttachedObjects(ctx, category, tag)
+	if err != nil {
+		return nil, err
+	}
+
+	var matched []types.ManagedObjectReference
+	seen := make(map[types.ManagedObjectReference]bool)
+
+	for _, ref := range refs {
+		if ref.Type == "VirtualMachine" && !seen[ref] {
+			seen[ref] = true
+			matched = append(matched, ref)
+		}
+	}
+
+	if len(matched) == 0 {
+		return nil, &NotFoundError{"vm", category + "/" + tag}
+	}
+
+	paths, err := f.displayPaths(ctx, matched)
+	if err != nil {
+		return nil, err
+	}
+
+	vms := make([]*object.VirtualMachine, len(matched))
+	for i, ref := range matched {
+		vms[i] = object.NewVirtualMachine(f.client, ref)
+		vms[i].InventoryPath = paths[ref]
+	}
+
+	return vms, nil
+}
@@ -0,0 +1,81 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package find
+
+import (
+	"context"
+	"testing"
+
+	"github.com/vmware/govmomi/vapi/tags"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// tags.Client is the REST implementation of TagAssociations.
+var _ TagAssociations = (*tags.Client)(nil)
+
+type tagAssociations map[string][]types.ManagedObjectReference
+
+func (t tagAssociations) AttachedObjects(_ context.Context, category string, tag string) ([]types.ManagedObjectReference, error) {
+	return t[category+"/"+tag], nil
+}
+
+func TestVirtualMachineListByTag(t *testing.T) {
+	inv := newInventory()
+	dc := inv.add(inv.root, "Datacenter", "dc")
+	vmFolder := inv.add(dc, "Folder", "vm")
+	web := inv.add(vmFolder, "Folder", "web")
+	vm1 := inv.add(web, "VirtualMachine", "vm1")
+	vm2 := inv.add(vmFolder, "VirtualMachine", "vm2")
+	host := inv.add(dc, "HostSystem", "esx1")
+
+	assoc := tagAssociations{
+		"team/web": {vm1, host, vm2, vm1},
+		"team/esx": {host},
+	}
+
+	ctx := context.Background()
+	f := inv.finder(false, dc)
+
+	ancestors := 0
+	f.Client().RoundTripper = roundTripFunc(func(ctx context.Context, req, res soap.HasFault) error {
+		if _, ok := req.(*methods.RetrievePropertiesBody); ok {
+			ancestors++
+		}
+		return inv.RoundTrip(ctx, req, res)
+	})
+
+	vms, err := f.VirtualMachineListByTag(ctx, assoc, "team", "web")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ancestors != 1 {
+		t.Errorf("expected 1 request, got %d", ancestors)
+	}
+
+	assertPaths(t, vms, []string{"/dc/vm/web/vm1", "/dc/vm/vm2"})
+
+	for _, tag := range []string{"esx", "enoent"} {
+		if _, err = f.VirtualMachineListByTag(ctx, assoc, "team", tag); err == nil {
+			t.Errorf("%s: expected NotFoundError", tag)
+		} else if _, ok := err.(*NotFoundError); !ok {
+			t.Errorf("%s: expected NotFoundError, got %v", tag, err)
+		}
+	}
+}
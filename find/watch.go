@@ -0,0 +1,209 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package find
+
+import (
+	"context"
+	"path"
+	"strings"
+
+	"github.com/vmware/govmomi/list"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// WatchUpdate is an update delivered by Watch.
+type WatchUpdate struct {
+	types.ObjectUpdate
+
+	// Err is set on the final update, sent before the channel is closed, when waiting for updates failed.
+	Err error
+}
+
+// Watch returns the elements matching the given paths, as ManagedObjectList does, along with a channel
+// of updates to the given properties of those elements.  If no property is given, "name" is used.
+//
+// Updates are collected by traversing the containers the paths are resolved in, such as the pivot folder
+// of a relative path, so objects created in those containers after the call are reported with kind "enter".
+// Objects of the types matched are watched; when nothing matches, any managed entity is watched and
+// props must then be ManagedEntity properties, such as "name".
+//
+// The first updates received contain the current value of the properties, followed by subsequent changes.
+// Updates are delivered from a new property collector, with its own filter, which is destroyed
+// and the channel closed when ctx is done or waiting for updates fails, in which case the error
+// is delivered in a final update.
+func (f *Finder) Watch(ctx context.Context, props []string, paths ...string) ([]list.Element, <-chan WatchUpdate, error) {
+	if len(paths) == 0 {
+		paths = []string{"*"}
+	}
+
+	if len(props) == 0 {
+		props = []string{"name"}
+	}
+
+	var snapshot []list.Element
+	var spec types.PropertyFilterSpec
+	seen := make(map[types.ManagedObjectReference]bool)
+	watched := make(map[types.ManagedObjectReference]bool)
+
+	for _, p := range paths {
+		es, err := f.ManagedObjectList(ctx, p)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for _, e := range es {
+			ref := e.Object.Reference()
+			if !seen[ref] {
+				seen[ref] = true
+				snapshot = append(snapshot, e)
+			}
+		}
+
+		containers, err := f.ManagedObjectList(ctx, path.Dir(p))
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for _, c := range containers {
+			ref := c.Object.Reference()
+			selection := childTraversal(ref)
+			if watched[ref] || len(selection) == 0 {
+				continue
+			}
+			watched[ref] = true
+
+			spec.ObjectSet = append(spec.ObjectSet, types.ObjectSpec{
+				Obj:       ref,
+				Skip:      types.NewBool(true),
+				SelectSet: selection,
+			})
+		}
+	}
+
+	if len(spec.ObjectSet) == 0 {
+		return nil, nil, &NotFoundError{"container", strings.Join(paths, " ")}
+	}
+
+	kinds := make(map[string]bool)
+
+	for _, e := range snapshot {
+		kind := e.Object.Reference().Type
+		if !kinds[kind] {
+			kinds[kind] = true
+			spec.PropSet = append(spec.PropSet, types.PropertySpec{Type: kind, PathSet: props})
+		}
+	}
+
+	if len(spec.PropSet) == 0 {
+		spec.PropSet = []types.PropertySpec{{Type: "ManagedEntity", PathSet: props}}
+	}
+
+	pc, err := f.recurser.Collector.Create(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = pc.CreateFilter(ctx, types.CreateFilter{Spec: spec})
+	if err != nil {
+		_ = pc.Destroy(context.Background())
+		return nil, nil, err
+	}
+
+	updates := make(chan WatchUpdate)
+
+	go func() {
+		defer close(updates)
+
+		// Attempt to destroy the collector using the background context, as ctx may have been cancelled.
+		defer pc.Destroy(context.Background())
+
+		for version := ""; ; {
+			req := types.WaitForUpdatesEx{
+				This:    pc.Reference(),
+				Version: version,
+			}
+
+			res, err := methods.WaitForUpdatesEx(ctx, f.client, &req)
+			if err != nil {
+				if ctx.Err() == nil {
+					select {
+					case updates <- WatchUpdate{Err: err}:
+					case <-ctx.Done():
+					}
+				}
+				return
+			}
+
+			// Retry if the result came back empty
+			set := res.Returnval
+			if set == nil {
+				continue
+			}
+
+			version = set.Version
+
+			for _, fs := range set.FilterSet {
+				for _, os := range fs.ObjectSet {
+					select {
+					case updates <- WatchUpdate{ObjectUpdate: os}:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+		}
+	}()
+
+	return snapshot, updates, nil
+}
+
+// childTraversal returns the selection of the children of ref, as listed by list.Lister,
+// or nil if ref is not a container.
+func childTraversal(ref types.ManagedObjectReference) []types.BaseSelectionSpec {
+	kind := ref.Type
+	var fields []string
+
+	switch kind {
+	case "Folder", "StoragePod":
+		kind = "Folder"
+		fields = []string{"childEntity"}
+	case "Datacenter":
+		fields = []string{"vmFolder", "hostFolder", "datastoreFolder", "networkFolder"}
+	case "ComputeResource", "ClusterComputeResource":
+		kind = "ComputeResource"
+		fields = []string{"host", "resourcePool"}
+	case "ResourcePool":
+		fields = []string{"resourcePool"}
+	case "HostSystem":
+		fields = []string{"datastore", "network", "vm"}
+	case "VirtualApp":
+		fields = []string{"resourcePool", "vm"}
+	}
+
+	var selection []types.BaseSelectionSpec
+
+	for _, field := range fields {
+		selection = append(selection, &types.TraversalSpec{
+			Type: kind,
+			Path: field,
+			Skip: types.NewBool(false),
+		})
+	}
+
+	return selection
+}
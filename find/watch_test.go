@@ -0,0 +1,132 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package find
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func TestWatch(t *testing.T) {
+	inv := newInventory()
+	dc := inv.add(inv.root, "Datacenter", "dc")
+	vmFolder := inv.add(dc, "Folder", "vm")
+	inv.add(vmFolder, "VirtualMachine", "vm1")
+	empty := inv.add(vmFolder, "Folder", "empty")
+
+	ctx := context.Background()
+	f := inv.finder(false, dc)
+
+	pc := types.ManagedObjectReference{Type: "PropertyCollector", Value: "session[watch]"}
+	vm2 := types.ManagedObjectReference{Type: "VirtualMachine", Value: "vm-new"}
+	timeout := errors.New("connection reset")
+
+	var spec types.PropertyFilterSpec
+	var waits int
+	var destroyed bool
+
+	f.Client().RoundTripper = roundTripFunc(func(ctx context.Context, req, res soap.HasFault) error {
+		switch body := req.(type) {
+		case *methods.CreatePropertyCollectorBody:
+			res.(*methods.CreatePropertyCollectorBody).Res = &types.CreatePropertyCollectorResponse{Returnval: pc}
+		case *methods.CreateFilterBody:
+			spec = body.Req.Spec
+			res.(*methods.CreateFilterBody).Res = &types.CreateFilterResponse{}
+		case *methods.WaitForUpdatesExBody:
+			waits++
+			if waits > 1 {
+				return timeout
+			}
+			res.(*methods.WaitForUpdatesExBody).Res = &types.WaitForUpdatesExResponse{
+				Returnval: &types.UpdateSet{
+					Version: "1",
+					FilterSet: []types.PropertyFilterUpdate{{
+						ObjectSet: []types.ObjectUpdate{{Kind: types.ObjectUpdateKindEnter, Obj: vm2}},
+					}},
+				},
+			}
+		case *methods.DestroyPropertyCollectorBody:
+			destroyed = body.Req.This == pc
+			res.(*methods.DestroyPropertyCollectorBody).Res = &types.DestroyPropertyCollectorResponse{}
+		default:
+			return inv.RoundTrip(ctx, req, res)
+		}
+		return nil
+	})
+
+	tests := []struct {
+		path   string
+		obj    types.ManagedObjectReference
+		kinds  []string
+		expect []string
+	}{
+		{"vm/vm*", vmFolder, []string{"VirtualMachine"}, []string{"/dc/vm/vm1"}},
+		{"vm/empty/*", empty, []string{"ManagedEntity"}, nil},
+	}
+
+	for _, test := range tests {
+		waits = 0
+		destroyed = false
+
+		snapshot, updates, err := f.Watch(ctx, nil, test.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assertPaths(t, snapshot, test.expect)
+
+		if len(spec.ObjectSet) != 1 || spec.ObjectSet[0].Obj != test.obj {
+			t.Fatalf("%s: expected filter on %s, got %#v", test.path, test.obj, spec.ObjectSet)
+		}
+
+		var kinds []string
+		for _, ps := range spec.PropSet {
+			kinds = append(kinds, ps.Type)
+		}
+		if !reflect.DeepEqual(kinds, test.kinds) {
+			t.Errorf("%s: expected types %s, got %s", test.path, test.kinds, kinds)
+		}
+
+		u := <-updates
+		if u.Err != nil || u.Obj != vm2 || u.Kind != types.ObjectUpdateKindEnter {
+			t.Errorf("%s: unexpected update %#v", test.path, u)
+		}
+
+		u = <-updates
+		if u.Err != timeout {
+			t.Errorf("%s: expected final update with error, got %#v", test.path, u)
+		}
+
+		if _, ok := <-updates; ok {
+			t.Errorf("%s: expected updates to be closed", test.path)
+		}
+
+		if !destroyed {
+			t.Errorf("%s: expected collector to be destroyed", test.path)
+		}
+	}
+
+	if _, _, err := f.Watch(ctx, nil, "enoent/*"); err == nil {
+		t.Error("expected error")
+	}
+}
@@ -60,7 +60,8 @@ load test_helper
 
   unset GOVC_NETWORK
   run govc vm.network.change -vm $vm $eth0
-  assert_failure "govc: default network resolves to multiple instances, please specify"
+  assert_failure
+  assert_matches "govc: default network resolves to multiple instances .*, please specify" "${output}"
 
   run govc vm.power -on $vm
   assert_success
@@ -125,7 +126,8 @@ load test_helper
   # -net flag is required when there are multiple networks
   unset GOVC_NETWORK
   run govc vm.create -on=false $(new_id)
-  assert_failure "govc: default network resolves to multiple instances, please specify"
+  assert_failure
+  assert_matches "govc: default network resolves to multiple instances .*, please specify" "${output}"
 }
 
 @test "network change hardware address" {
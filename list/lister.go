@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"path"
 	"reflect"
+	"strings"
 
 	"github.com/vmware/govmomi/property"
 	"github.com/vmware/govmomi/vim25/mo"
@@ -105,11 +106,47 @@ func ToElement(r mo.Reference, prefix string) Element {
 	return e
 }
 
+// Source retrieves the properties of managed objects for a Lister, and is implemented by *property.Collector.
+// Other implementations can provide an inventory without a vCenter or ESX connection.
+type Source interface {
+	RetrieveProperties(ctx context.Context, req types.RetrieveProperties) (*types.RetrievePropertiesResponse, error)
+}
+
 type Lister struct {
 	Collector *property.Collector
 	Reference types.ManagedObjectReference
 	Prefix    string
 	All       bool
+
+	// SkipHidden configures the lister to omit child folders tagged as hidden, see IsHidden.
+	SkipHidden bool
+
+	// Properties configures additional properties to retrieve, keyed by managed object type.
+	// This field is ignored when All is true.
+	Properties map[string][]string
+
+	// Source, if set, is used to retrieve properties instead of Collector.
+	Source Source
+}
+
+// pathSet returns the properties to retrieve for the given type when All is false.
+func (l Lister) pathSet(kind string) []string {
+	return append([]string{"name"}, l.Properties[kind]...)
+}
+
+// HiddenTagPrefix is the tag key prefix vSphere uses to mark system managed entities.
+const HiddenTagPrefix = "SYSTEM/"
+
+// IsHidden returns true if the given Folder has been tagged as a system folder,
+// which is typically hidden from inventory listings.
+func IsHidden(f mo.Folder) bool {
+	for _, tag := range f.Tag {
+		if strings.HasPrefix(tag.Key, HiddenTagPrefix) {
+			return true
+		}
+	}
+
+	return false
 }
 
 func traversable(ref types.ManagedObjectReference) bool {
@@ -130,7 +167,12 @@ func traversable(ref types.ManagedObjectReference) bool {
 }
 
 func (l Lister) retrieveProperties(ctx context.Context, req types.RetrieveProperties, dst *[]interface{}) error {
-	res, err := l.Collector.RetrieveProperties(ctx, req)
+	var src Source = l.Collector
+	if l.Source != nil {
+		src = l.Source
+	}
+
+	res, err := src.RetrieveProperties(ctx, req)
 	if err != nil {
 		return err
 	}
@@ -221,7 +263,7 @@ func (l Lister) ListFolder(ctx context.Context) ([]Element, error) {
 		if l.All {
 			pspec.All = types.NewBool(true)
 		} else {
-			pspec.PathSet = []string{"name"}
+			pspec.PathSet = l.pathSet(t)
 
 			// Additional basic properties.
 			switch t {
@@ -230,12 +272,28 @@ func (l Lister) ListFolder(ctx context.Context) ([]Element, error) {
 				// the ResourcePoolFlag. Make sure they always have their resourcePool
 				// field populated.
 				pspec.PathSet = append(pspec.PathSet, "resourcePool")
+			case "Folder":
+				if l.SkipHidden {
+					pspec.PathSet = append(pspec.PathSet, "tag")
+				}
 			}
 		}
 
 		spec.PropSet = append(spec.PropSet, pspec)
 	}
 
+	if !l.All {
+		// Properties of subtypes, such as DistributedVirtualPortgroup, need their own spec.
+		for t := range l.Properties {
+			if !isChildType(childTypes, t) {
+				spec.PropSet = append(spec.PropSet, types.PropertySpec{
+					Type:    t,
+					PathSet: l.pathSet(t),
+				})
+			}
+		}
+	}
+
 	req := types.RetrieveProperties{
 		SpecSet: []types.PropertyFilterSpec{spec},
 	}
@@ -249,12 +307,25 @@ func (l Lister) ListFolder(ctx context.Context) ([]Element, error) {
 
 	es := []Element{}
 	for _, v := range dst {
+		if f, ok := v.(mo.Folder); ok && l.SkipHidden && IsHidden(f) {
+			continue
+		}
+
 		es = append(es, ToElement(v.(mo.Reference), l.Prefix))
 	}
 
 	return es, nil
 }
 
+func isChildType(types []string, t string) bool {
+	for _, c := range types {
+		if c == t {
+			return true
+		}
+	}
+	return false
+}
+
 func (l Lister) ListDatacenter(ctx context.Context) ([]Element, error) {
 	ospec := types.ObjectSpec{
 		Obj:  l.Reference,
@@ -286,7 +357,7 @@ func (l Lister) ListDatacenter(ctx context.Context) ([]Element, error) {
 	if l.All {
 		pspec.All = types.NewBool(true)
 	} else {
-		pspec.PathSet = []string{"name"}
+		pspec.PathSet = l.pathSet(pspec.Type)
 	}
 
 	req := types.RetrieveProperties{
@@ -348,7 +419,7 @@ func (l Lister) ListComputeResource(ctx context.Context) ([]Element, error) {
 		if l.All {
 			pspec.All = types.NewBool(true)
 		} else {
-			pspec.PathSet = []string{"name"}
+			pspec.PathSet = l.pathSet(t)
 		}
 
 		pspecs = append(pspecs, pspec)
@@ -411,7 +482,7 @@ func (l Lister) ListResourcePool(ctx context.Context) ([]Element, error) {
 		if l.All {
 			pspec.All = types.NewBool(true)
 		} else {
-			pspec.PathSet = []string{"name"}
+			pspec.PathSet = l.pathSet(t)
 		}
 
 		pspecs = append(pspecs, pspec)
@@ -478,7 +549,7 @@ func (l Lister) ListHostSystem(ctx context.Context) ([]Element, error) {
 		if l.All {
 			pspec.All = types.NewBool(true)
 		} else {
-			pspec.PathSet = []string{"name"}
+			pspec.PathSet = l.pathSet(t)
 		}
 
 		pspecs = append(pspecs, pspec)
@@ -543,7 +614,7 @@ func (l Lister) ListVirtualApp(ctx context.Context) ([]Element, error) {
 		if l.All {
 			pspec.All = types.NewBool(true)
 		} else {
-			pspec.PathSet = []string{"name"}
+			pspec.PathSet = l.pathSet(t)
 		}
 
 		pspecs = append(pspecs, pspec)
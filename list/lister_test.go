@@ -0,0 +1,48 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package list
+
+import (
+	"testing"
+
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func TestIsHidden(t *testing.T) {
+	tests := []struct {
+		Tags   []string
+		Hidden bool
+	}{
+		{nil, false},
+		{[]string{"user/tag"}, false},
+		{[]string{"SYSTEM/DO_NOT_DELETE"}, true},
+		{[]string{"user/tag", "SYSTEM/COM.VMWARE.VIM.VC"}, true},
+	}
+
+	for _, test := range tests {
+		var f mo.Folder
+
+		for _, key := range test.Tags {
+			f.Tag = append(f.Tag, types.Tag{Key: key})
+		}
+
+		if IsHidden(f) != test.Hidden {
+			t.Errorf("IsHidden(%v) != %t", test.Tags, test.Hidden)
+		}
+	}
+}
@@ -22,6 +22,7 @@ import (
 	"path/filepath"
 
 	"github.com/vmware/govmomi/property"
+	"github.com/vmware/govmomi/vim25/types"
 )
 
 type Recurser struct {
@@ -35,9 +36,37 @@ type Recurser struct {
 	// a folder means listing its contents. This is typically set to false for
 	// commands that take managed entities that are not folders as input.
 	TraverseLeafs bool
+
+	// SkipHidden configures the recurser to omit folders tagged as hidden system folders,
+	// along with everything below them.  This is independent of the All field:
+	//
+	//   All=false, SkipHidden=false: all folders are listed, leaf nodes have only basic properties
+	//   All=true,  SkipHidden=false: all folders are listed, leaf nodes have all properties
+	//   All=false, SkipHidden=true:  hidden folders are omitted, leaf nodes have only basic properties
+	//   All=true,  SkipHidden=true:  hidden folders are omitted, leaf nodes have all properties
+	//
+	// Hidden folders are omitted even when matched explicitly by name.
+	SkipHidden bool
+
+	// Properties configures additional properties to retrieve for each type of managed object,
+	// see Lister.Properties.
+	Properties map[string][]string
+
+	// Exclude configures patterns of inventory paths to omit, along with everything below them.
+	// Patterns are matched against the full path of each element using path.Match.  Matching
+	// elements are pruned before they are listed, such that excluded subtrees are never fetched.
+	Exclude []string
+
+	// Source, if set, is used to retrieve properties instead of Collector, see Lister.Source.
+	Source Source
 }
 
 func (r Recurser) Recurse(ctx context.Context, root Element, parts []string) ([]Element, error) {
+	excluded, err := r.excluded(root)
+	if err != nil || excluded {
+		return nil, err
+	}
+
 	if len(parts) == 0 {
 		// Include non-traversable leaf elements in result. For example, consider
 		// the pattern "./vm/my-vm-*", where the pattern should match the VMs and
@@ -55,6 +84,10 @@ func (r Recurser) Recurse(ctx context.Context, root Element, parts []string) ([]
 		Collector: r.Collector,
 		Reference: root.Object.Reference(),
 		Prefix:    root.Path,
+
+		SkipHidden: r.SkipHidden,
+		Properties: r.Properties,
+		Source:     r.Source,
 	}
 
 	if r.All && len(parts) < 2 {
@@ -68,7 +101,18 @@ func (r Recurser) Recurse(ctx context.Context, root Element, parts []string) ([]
 
 	// This folder is a leaf as far as the glob goes.
 	if len(parts) == 0 {
-		return in, nil
+		var out []Element
+		for _, e := range in {
+			excluded, err := r.excluded(e)
+			if err != nil {
+				return nil, err
+			}
+
+			if !excluded {
+				out = append(out, e)
+			}
+		}
+		return out, nil
 	}
 
 	pattern := parts[0]
@@ -76,7 +120,7 @@ func (r Recurser) Recurse(ctx context.Context, root Element, parts []string) ([]
 
 	var out []Element
 	for _, e := range in {
-		matched, err := filepath.Match(pattern, path.Base(e.Path))
+		matched, err := match(pattern, e)
 		if err != nil {
 			return nil, err
 		}
@@ -95,3 +139,26 @@ func (r Recurser) Recurse(ctx context.Context, root Element, parts []string) ([]
 
 	return out, nil
 }
+
+// excluded returns true if the path of the given element matches any of the Exclude patterns.
+func (r Recurser) excluded(e Element) (bool, error) {
+	for _, pattern := range r.Exclude {
+		matched, err := path.Match(pattern, e.Path)
+		if err != nil || matched {
+			return matched, err
+		}
+	}
+
+	return false, nil
+}
+
+// match returns true if the name of the given element matches the pattern, or if the pattern is of
+// the form "Type:value" and is equal to the element's managed object reference.
+func match(pattern string, e Element) (bool, error) {
+	var ref types.ManagedObjectReference
+	if ref.FromString(pattern) && ref == e.Object.Reference() {
+		return true, nil
+	}
+
+	return filepath.Match(pattern, path.Base(e.Path))
+}
@@ -135,6 +135,23 @@ func (m AuthorizationManager) RetrieveAllPermissions(ctx context.Context) ([]typ
 	return res.Returnval, nil
 }
 
+// HasPrivilegeOnEntities returns whether the user of the given session has each of the given privileges on each entity.
+func (m AuthorizationManager) HasPrivilegeOnEntities(ctx context.Context, entities []types.ManagedObjectReference, sessionID string, privIDs []string) ([]types.EntityPrivilege, error) {
+	req := types.HasPrivilegeOnEntities{
+		This:      m.Reference(),
+		Entity:    entities,
+		SessionId: sessionID,
+		PrivId:    privIDs,
+	}
+
+	res, err := methods.HasPrivilegeOnEntities(ctx, m.Client(), &req)
+	if err != nil {
+		return nil, err
+	}
+
+	return res.Returnval, nil
+}
+
 func (m AuthorizationManager) AddRole(ctx context.Context, name string, ids []string) (int32, error) {
 	req := types.AddAuthorizationRole{
 		This:    m.Reference(),
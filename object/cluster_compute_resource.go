@@ -18,9 +18,11 @@ package object
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/vmware/govmomi/vim25"
 	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/mo"
 	"github.com/vmware/govmomi/vim25/types"
 )
 
@@ -34,6 +36,43 @@ func NewClusterComputeResource(c *vim25.Client, ref types.ManagedObjectReference
 	}
 }
 
+// Configuration returns the cluster's configurationEx property, including its DRS and HA settings.
+func (c ClusterComputeResource) Configuration(ctx context.Context) (*types.ClusterConfigInfoEx, error) {
+	var obj mo.ClusterComputeResource
+
+	err := c.Properties(ctx, c.Reference(), []string{"configurationEx"}, &obj)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, ok := obj.ConfigurationEx.(*types.ClusterConfigInfoEx)
+	if !ok {
+		return nil, fmt.Errorf("unexpected configuration type %T", obj.ConfigurationEx)
+	}
+
+	return cfg, nil
+}
+
+// DRSEnabled returns true if DRS is enabled for the cluster.
+func (c ClusterComputeResource) DRSEnabled(ctx context.Context) (bool, error) {
+	cfg, err := c.Configuration(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	return cfg.DrsConfig.Enabled != nil && *cfg.DrsConfig.Enabled, nil
+}
+
+// HAEnabled returns true if vSphere HA is enabled for the cluster.
+func (c ClusterComputeResource) HAEnabled(ctx context.Context) (bool, error) {
+	cfg, err := c.Configuration(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	return cfg.DasConfig.Enabled != nil && *cfg.DasConfig.Enabled, nil
+}
+
 func (c ClusterComputeResource) ReconfigureCluster(ctx context.Context, spec types.ClusterConfigSpec) (*Task, error) {
 	req := types.ReconfigureCluster_Task{
 		This:   c.Reference(),
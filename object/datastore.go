@@ -32,6 +32,7 @@ import (
 	"github.com/vmware/govmomi/session"
 	"github.com/vmware/govmomi/vim25"
 	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/progress"
 	"github.com/vmware/govmomi/vim25/soap"
 	"github.com/vmware/govmomi/vim25/types"
 )
@@ -314,6 +315,103 @@ func (d Datastore) DownloadFile(ctx context.Context, path string, file string, p
 	return d.Client().DownloadFile(file, u, p)
 }
 
+// ResumeDownloadFile via soap.Download with an http service ticket, continuing from the end of
+// an existing local file by means of a ranged request.  If the local file does not exist, the full
+// file is downloaded.
+func (d Datastore) ResumeDownloadFile(ctx context.Context, path string, file string, param *soap.Download) error {
+	var offset int64
+
+	if s, err := os.Stat(file); err == nil {
+		offset = s.Size()
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	u, p, err := d.downloadTicket(ctx, path, param)
+	if err != nil {
+		return err
+	}
+
+	if offset == 0 {
+		return d.Client().DownloadFile(file, u, p)
+	}
+
+	return resumeDownloadFile(d.Client().Client, u, p, file, offset)
+}
+
+// resumeDownloadFile appends the remote file from offset to the local file, which must have offset bytes.
+// The local file is overwritten if the server does not support the ranged request.
+func resumeDownloadFile(c *soap.Client, u *url.URL, p *soap.Download, file string, offset int64) error {
+	headers := make(map[string]string, len(p.Headers)+1)
+	for k, v := range p.Headers {
+		headers[k] = v
+	}
+	headers["Range"] = fmt.Sprintf("bytes=%d-", offset)
+	p.Headers = headers
+
+	res, err := c.DownloadRequest(u, p)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusPartialContent:
+		var start, end, size int64
+		cr := res.Header.Get("Content-Range")
+		if _, err = fmt.Sscanf(cr, "bytes %d-%d/%d", &start, &end, &size); err != nil || start != offset {
+			return fmt.Errorf("download %s: unexpected Content-Range %q for offset %d", u, cr, offset)
+		}
+	case http.StatusRequestedRangeNotSatisfiable:
+		// The local file is complete if it has the size of the remote file
+		var size int64
+		cr := res.Header.Get("Content-Range")
+		if _, err = fmt.Sscanf(cr, "bytes */%d", &size); err != nil {
+			return fmt.Errorf("download %s: %s without remote file size", u, res.Status)
+		}
+		if size != offset {
+			return fmt.Errorf("download %s: local file %s has %d bytes, remote file has %d", u, file, offset, size)
+		}
+		return nil
+	case http.StatusOK:
+		// Server ignored the Range header, start over
+		offset = 0
+	default:
+		return fmt.Errorf("download %s: %s", u, res.Status)
+	}
+
+	flag := os.O_WRONLY | os.O_CREATE
+	if offset == 0 {
+		flag |= os.O_TRUNC
+	} else {
+		flag |= os.O_APPEND
+	}
+
+	fh, err := os.OpenFile(file, flag, 0644)
+	if err != nil {
+		return err
+	}
+
+	var r io.Reader = res.Body
+
+	if p.Progress != nil {
+		pr := progress.NewReader(p.Progress, r, res.ContentLength)
+		r = pr
+
+		defer func() {
+			pr.Done(err)
+		}()
+	}
+
+	if _, err = io.Copy(fh, r); err != nil {
+		_ = fh.Close()
+		return err
+	}
+
+	err = fh.Close()
+	return err
+}
+
 // AttachedHosts returns hosts that have this Datastore attached, accessible and writable.
 func (d Datastore) AttachedHosts(ctx context.Context) ([]*HostSystem, error) {
 	var ds mo.Datastore
@@ -383,12 +481,93 @@ func (d Datastore) AttachedClusterHosts(ctx context.Context, cluster *ComputeRes
 }
 
 func (d Datastore) Stat(ctx context.Context, file string) (types.BaseFileInfo, error) {
-	b, err := d.Browser(ctx)
-	if err != nil {
-		return nil, err
+	spec := types.HostDatastoreBrowserSearchSpec{
+		Details: &types.FileQueryFlags{
+			FileType:     true,
+			FileSize:     true,
+			Modification: true,
+			FileOwner:    types.NewBool(true),
+		},
+		MatchPattern: []string{path.Base(file)},
+	}
+
+	return d.stat(ctx, file, &spec)
+}
+
+// DatastoreFileType is the type of a datastore file, as determined by the HostDatastoreBrowser.
+type DatastoreFileType string
+
+const (
+	DatastoreFileTypeFolder         = DatastoreFileType("Folder")
+	DatastoreFileTypeVmDisk         = DatastoreFileType("VmDisk")
+	DatastoreFileTypeIsoImage       = DatastoreFileType("IsoImage")
+	DatastoreFileTypeFloppyImage    = DatastoreFileType("FloppyImage")
+	DatastoreFileTypeVmConfig       = DatastoreFileType("VmConfig")
+	DatastoreFileTypeTemplateConfig = DatastoreFileType("TemplateConfig")
+	DatastoreFileTypeVmLog          = DatastoreFileType("VmLog")
+	DatastoreFileTypeVmNvram        = DatastoreFileType("VmNvram")
+	DatastoreFileTypeVmSnapshot     = DatastoreFileType("VmSnapshot")
+	DatastoreFileTypeFile           = DatastoreFileType("File")
+)
+
+// FileTypeOf returns the type of the given file info, such as that returned by Datastore.Stat.
+// DatastoreFileTypeFile is returned for files of any other type.
+func FileTypeOf(info types.BaseFileInfo) DatastoreFileType {
+	switch info.(type) {
+	case *types.FolderFileInfo:
+		return DatastoreFileTypeFolder
+	case *types.VmDiskFileInfo:
+		return DatastoreFileTypeVmDisk
+	case *types.IsoImageFileInfo:
+		return DatastoreFileTypeIsoImage
+	case *types.FloppyImageFileInfo:
+		return DatastoreFileTypeFloppyImage
+	case *types.TemplateConfigFileInfo:
+		return DatastoreFileTypeTemplateConfig
+	case *types.VmConfigFileInfo:
+		return DatastoreFileTypeVmConfig
+	case *types.VmLogFileInfo:
+		return DatastoreFileTypeVmLog
+	case *types.VmNvramFileInfo:
+		return DatastoreFileTypeVmNvram
+	case *types.VmSnapshotFileInfo:
+		return DatastoreFileTypeVmSnapshot
+	default:
+		return DatastoreFileTypeFile
 	}
+}
+
+// DatastoreFileStat is the result of Datastore.StatType.  Info is one of the FileInfo types, such as
+// *types.VmDiskFileInfo when Type is DatastoreFileTypeVmDisk, or *types.FileInfo for other files.
+type DatastoreFileStat struct {
+	Type DatastoreFileType
+	Info types.BaseFileInfo
+}
 
+// StatType is like Stat, but queries the HostDatastoreBrowser for each of the known file types, such that
+// the result distinguishes folders, disks, ISO images and so on.  For disks, the disk type and capacity are included.
+func (d Datastore) StatType(ctx context.Context, file string) (*DatastoreFileStat, error) {
 	spec := types.HostDatastoreBrowserSearchSpec{
+		Query: []types.BaseFileQuery{
+			&types.FolderFileQuery{},
+			&types.VmDiskFileQuery{
+				Details: &types.VmDiskFileQueryFlags{
+					DiskType:        true,
+					CapacityKb:      true,
+					HardwareVersion: true,
+					Thin:            types.NewBool(true),
+				},
+			},
+			&types.IsoImageFileQuery{},
+			&types.FloppyImageFileQuery{},
+			&types.TemplateConfigFileQuery{},
+			&types.VmConfigFileQuery{},
+			&types.VmLogFileQuery{},
+			&types.VmNvramFileQuery{},
+			&types.VmSnapshotFileQuery{},
+			// Matches files of any other type
+			&types.FileQuery{},
+		},
 		Details: &types.FileQueryFlags{
 			FileType:     true,
 			FileSize:     true,
@@ -398,8 +577,23 @@ func (d Datastore) Stat(ctx context.Context, file string) (types.BaseFileInfo, e
 		MatchPattern: []string{path.Base(file)},
 	}
 
+	info, err := d.stat(ctx, file, &spec)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DatastoreFileStat{FileTypeOf(info), info}, nil
+}
+
+// stat searches the parent directory of file with the given spec, returning the first result.
+func (d Datastore) stat(ctx context.Context, file string, spec *types.HostDatastoreBrowserSearchSpec) (types.BaseFileInfo, error) {
+	b, err := d.Browser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	dsPath := d.Path(path.Dir(file))
-	task, err := b.SearchDatastore(ctx, dsPath, &spec)
+	task, err := b.SearchDatastore(ctx, dsPath, spec)
 	if err != nil {
 		return nil, err
 	}
@@ -424,7 +618,55 @@ func (d Datastore) Stat(ctx context.Context, file string) (types.BaseFileInfo, e
 	}
 
 	return res.File[0], nil
+}
+
+// datacenter returns the Datacenter containing the datastore, as required by the FileManager.
+func (d Datastore) datacenter(ctx context.Context) (*Datacenter, error) {
+	mes, err := mo.Ancestors(ctx, d.c, d.c.ServiceContent.PropertyCollector, d.Reference())
+	if err != nil {
+		return nil, err
+	}
+
+	for _, me := range mes {
+		if me.Self.Type == "Datacenter" {
+			dc := NewDatacenter(d.c, me.Self)
+			dc.InventoryPath = d.DatacenterPath
+			return dc, nil
+		}
+	}
+
+	return nil, fmt.Errorf("datacenter of %s not found", d.Reference())
+}
+
+// MakeDirectory creates the given directory on the datastore, relative to its root.
+// If createParents is true, missing parent directories are created and an existing directory is not an error.
+func (d Datastore) MakeDirectory(ctx context.Context, path string, createParents bool) error {
+	dc, err := d.datacenter(ctx)
+	if err != nil {
+		return err
+	}
+
+	m := NewFileManager(d.c)
+	err = m.MakeDirectory(ctx, d.Path(path), dc, createParents)
+
+	if err != nil && createParents && soap.IsSoapFault(err) {
+		if _, ok := soap.ToSoapFault(err).VimFault().(types.FileAlreadyExists); ok {
+			return nil
+		}
+	}
+
+	return err
+}
+
+// DeleteFile deletes the given file or directory from the datastore, relative to its root.
+func (d Datastore) DeleteFile(ctx context.Context, path string) (*Task, error) {
+	dc, err := d.datacenter(ctx)
+	if err != nil {
+		return nil, err
+	}
 
+	m := NewFileManager(d.c)
+	return m.DeleteDatastoreFile(ctx, d.Path(path), dc)
 }
 
 // Type returns the type of file system volume.
@@ -16,5 +16,103 @@ limitations under the License.
 
 package object
 
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
 // Datastore should implement the Reference interface.
 var _ Reference = Datastore{}
+
+func TestFileTypeOf(t *testing.T) {
+	tests := []struct {
+		info   types.BaseFileInfo
+		expect DatastoreFileType
+	}{
+		{&types.FolderFileInfo{}, DatastoreFileTypeFolder},
+		{&types.VmDiskFileInfo{}, DatastoreFileTypeVmDisk},
+		{&types.IsoImageFileInfo{}, DatastoreFileTypeIsoImage},
+		{&types.FloppyImageFileInfo{}, DatastoreFileTypeFloppyImage},
+		{&types.VmConfigFileInfo{}, DatastoreFileTypeVmConfig},
+		{&types.TemplateConfigFileInfo{}, DatastoreFileTypeTemplateConfig},
+		{&types.VmLogFileInfo{}, DatastoreFileTypeVmLog},
+		{&types.VmNvramFileInfo{}, DatastoreFileTypeVmNvram},
+		{&types.VmSnapshotFileInfo{}, DatastoreFileTypeVmSnapshot},
+		{&types.FileInfo{}, DatastoreFileTypeFile},
+	}
+
+	for _, test := range tests {
+		if got := FileTypeOf(test.info); got != test.expect {
+			t.Errorf("%T: expected %s, got %s", test.info, test.expect, got)
+		}
+	}
+}
+
+func TestResumeDownloadFile(t *testing.T) {
+	content := "0123456789"
+
+	tests := []struct {
+		local  string
+		status int
+		expect string
+		fail   bool
+	}{
+		{"01234", http.StatusPartialContent, content, false},
+		{"01234", http.StatusOK, content, false},
+		{"xxxxx", http.StatusOK, content, false},
+		{content, http.StatusRequestedRangeNotSatisfiable, content, false},
+		{content + "more", http.StatusRequestedRangeNotSatisfiable, content + "more", true},
+	}
+
+	for _, test := range tests {
+		s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var offset int
+			if _, err := fmt.Sscanf(r.Header.Get("Range"), "bytes=%d-", &offset); err != nil {
+				t.Errorf("unexpected Range %q", r.Header.Get("Range"))
+			}
+
+			switch test.status {
+			case http.StatusPartialContent:
+				w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", offset, len(content)-1, len(content)))
+				w.WriteHeader(test.status)
+				_, _ = w.Write([]byte(content[offset:]))
+			case http.StatusRequestedRangeNotSatisfiable:
+				w.Header().Set("Content-Range", fmt.Sprintf("bytes */%d", len(content)))
+				w.WriteHeader(test.status)
+			default:
+				_, _ = w.Write([]byte(content))
+			}
+		}))
+
+		f, err := ioutil.TempFile("", "download")
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = f.WriteString(test.local)
+		_ = f.Close()
+
+		u, _ := url.Parse(s.URL + "/folder/file")
+		p := soap.DefaultDownload
+
+		err = resumeDownloadFile(soap.NewClient(u, true), u, &p, f.Name(), int64(len(test.local)))
+		if (err != nil) != test.fail {
+			t.Errorf("%d %q: unexpected error: %v", test.status, test.local, err)
+		}
+
+		b, _ := ioutil.ReadFile(f.Name())
+		if string(b) != test.expect {
+			t.Errorf("%d %q: expected %q, got %q", test.status, test.local, test.expect, b)
+		}
+
+		_ = os.Remove(f.Name())
+		s.Close()
+	}
+}
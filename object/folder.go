@@ -18,6 +18,8 @@ package object
 
 import (
 	"context"
+	"errors"
+	"path"
 
 	"github.com/vmware/govmomi/vim25"
 	"github.com/vmware/govmomi/vim25/methods"
@@ -98,6 +100,9 @@ func (f Folder) CreateCluster(ctx context.Context, cluster string, spec types.Cl
 	return NewClusterComputeResource(f.c, res.Returnval), nil
 }
 
+// CreateFolder creates a child folder with the given name.
+// If the InventoryPath of this folder is set, the InventoryPath of the new folder is set to match,
+// such that a folder returned by the Finder can be used to build out a folder hierarchy.
 func (f Folder) CreateFolder(ctx context.Context, name string) (*Folder, error) {
 	req := types.CreateFolder{
 		This: f.Reference(),
@@ -109,7 +114,12 @@ func (f Folder) CreateFolder(ctx context.Context, name string) (*Folder, error)
 		return nil, err
 	}
 
-	return NewFolder(f.c, res.Returnval), err
+	folder := NewFolder(f.c, res.Returnval)
+	if f.InventoryPath != "" {
+		folder.InventoryPath = path.Join(f.InventoryPath, name)
+	}
+
+	return folder, nil
 }
 
 func (f Folder) CreateStoragePod(ctx context.Context, name string) (*StoragePod, error) {
@@ -169,6 +179,8 @@ func (f Folder) CreateVM(ctx context.Context, config types.VirtualMachineConfigS
 	return NewTask(f.c, res.Returnval), nil
 }
 
+// RegisterVM adds an existing VM to the inventory of this folder, given the datastore path of its .vmx file.
+// The pool is required unless registering a template, host is optional when pool refers to a cluster.
 func (f Folder) RegisterVM(ctx context.Context, path string, name string, asTemplate bool, pool *ResourcePool, host *HostSystem) (*Task, error) {
 	req := types.RegisterVM_Task{
 		This:       f.Reference(),
@@ -212,7 +224,13 @@ func (f Folder) CreateDVS(ctx context.Context, spec types.DVSCreateSpec) (*Task,
 	return NewTask(f.c, res.Returnval), nil
 }
 
+// MoveInto moves the given objects, such as VMs or hosts, into the folder as a single task.
+// The objects must be of a type the folder can contain.
 func (f Folder) MoveInto(ctx context.Context, list []types.ManagedObjectReference) (*Task, error) {
+	if len(list) == 0 {
+		return nil, errors.New("no objects to move")
+	}
+
 	req := types.MoveIntoFolder_Task{
 		This: f.Reference(),
 		List: list,
@@ -20,6 +20,7 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"time"
 
 	"github.com/vmware/govmomi/vim25"
 	"github.com/vmware/govmomi/vim25/methods"
@@ -81,9 +82,13 @@ func (h HostSystem) ManagementIPs(ctx context.Context) ([]net.IP, error) {
 		return nil, err
 	}
 
+	if mh.Config == nil || mh.Config.VirtualNicManagerInfo == nil {
+		return nil, fmt.Errorf("virtualNicManagerInfo of %s is not available", h.Reference())
+	}
+
 	var ips []net.IP
 	for _, nc := range mh.Config.VirtualNicManagerInfo.NetConfig {
-		if nc.NicType == "management" && len(nc.CandidateVnic) > 0 {
+		if nc.NicType == "management" && len(nc.CandidateVnic) > 0 && nc.CandidateVnic[0].Spec.Ip != nil {
 			ip := net.ParseIP(nc.CandidateVnic[0].Spec.Ip.IpAddress)
 			if ip != nil {
 				ips = append(ips, ip)
@@ -94,6 +99,184 @@ func (h HostSystem) ManagementIPs(ctx context.Context) ([]net.IP, error) {
 	return ips, nil
 }
 
+// VMotionEnabledVNic returns the device name of the virtual NIC selected for vMotion, such as "vmk1".
+// An empty string is returned if vMotion is not enabled on any virtual NIC.
+func (h HostSystem) VMotionEnabledVNic(ctx context.Context) (string, error) {
+	var mh mo.HostSystem
+
+	err := h.Properties(ctx, h.Reference(), []string{"config.virtualNicManagerInfo.netConfig"}, &mh)
+	if err != nil {
+		return "", err
+	}
+
+	if mh.Config == nil || mh.Config.VirtualNicManagerInfo == nil {
+		return "", fmt.Errorf("virtualNicManagerInfo of %s is not available", h.Reference())
+	}
+
+	for _, nc := range mh.Config.VirtualNicManagerInfo.NetConfig {
+		if nc.NicType != string(types.HostVirtualNicManagerNicTypeVmotion) {
+			continue
+		}
+
+		for _, key := range nc.SelectedVnic {
+			for _, vnic := range nc.CandidateVnic {
+				if vnic.Key == key {
+					return vnic.Device, nil
+				}
+			}
+		}
+	}
+
+	return "", nil
+}
+
+// Capabilities returns the capability property of the host, such as support for vMotion
+// and maintenance mode.  The property is unset while the host is disconnected.
+func (h HostSystem) Capabilities(ctx context.Context) (types.HostCapability, error) {
+	var mh mo.HostSystem
+
+	err := h.Properties(ctx, h.Reference(), []string{"capability"}, &mh)
+	if err != nil {
+		return types.HostCapability{}, err
+	}
+
+	if mh.Capability == nil {
+		return types.HostCapability{}, fmt.Errorf("capability of %s is not available", h.Reference())
+	}
+
+	return *mh.Capability, nil
+}
+
+// EVCMode returns the key of the EVC mode currently in effect for the host.
+// For a host in a cluster, this is the mode of the cluster; otherwise the mode of the host itself.
+// An empty string is returned if EVC is disabled.
+func (h HostSystem) EVCMode(ctx context.Context) (string, error) {
+	var mh mo.HostSystem
+
+	err := h.Properties(ctx, h.Reference(), []string{"parent", "summary.currentEVCModeKey"}, &mh)
+	if err != nil {
+		return "", err
+	}
+
+	if mh.Parent == nil || mh.Parent.Type != "ClusterComputeResource" {
+		return mh.Summary.CurrentEVCModeKey, nil
+	}
+
+	var mcc mo.ClusterComputeResource
+
+	err = h.Properties(ctx, *mh.Parent, []string{"summary"}, &mcc)
+	if err != nil {
+		return "", err
+	}
+
+	if s, ok := mcc.Summary.(*types.ClusterComputeResourceSummary); ok {
+		return s.CurrentEVCModeKey, nil
+	}
+
+	return mh.Summary.CurrentEVCModeKey, nil
+}
+
+// NTPServers returns the NTP servers configured for the host, from config.dateTimeInfo.
+func (h HostSystem) NTPServers(ctx context.Context) ([]string, error) {
+	var mh mo.HostSystem
+
+	err := h.Properties(ctx, h.Reference(), []string{"config.dateTimeInfo"}, &mh)
+	if err != nil {
+		return nil, err
+	}
+
+	if mh.Config == nil || mh.Config.DateTimeInfo == nil {
+		return nil, fmt.Errorf("dateTimeInfo of %s is not available", h.Reference())
+	}
+
+	if mh.Config.DateTimeInfo.NtpConfig == nil {
+		return nil, nil
+	}
+
+	return mh.Config.DateTimeInfo.NtpConfig.Server, nil
+}
+
+// CurrentTime returns the current time of the host, as reported by its HostDateTimeSystem.
+func (h HostSystem) CurrentTime(ctx context.Context) (time.Time, error) {
+	s, err := h.ConfigManager().DateTimeSystem(ctx)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	t, err := s.Query(ctx)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return *t, nil
+}
+
+// ServiceInfo returns the services of the host, such as SSH and NTP, from config.service.
+func (h HostSystem) ServiceInfo(ctx context.Context) (*types.HostServiceInfo, error) {
+	var mh mo.HostSystem
+
+	err := h.Properties(ctx, h.Reference(), []string{"config.service"}, &mh)
+	if err != nil {
+		return nil, err
+	}
+
+	if mh.Config == nil || mh.Config.Service == nil {
+		return nil, fmt.Errorf("service info of %s is not available", h.Reference())
+	}
+
+	return mh.Config.Service, nil
+}
+
+// SSHEnabled returns true if the SSH service ("TSM-SSH") of the host is running.
+func (h HostSystem) SSHEnabled(ctx context.Context) (bool, error) {
+	info, err := h.ServiceInfo(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	for _, s := range info.Service {
+		if s.Key == "TSM-SSH" {
+			return s.Running, nil
+		}
+	}
+
+	return false, nil
+}
+
+// ProxySwitch returns the host proxy switch of the distributed virtual switch that the given portgroup belongs to,
+// from config.network.proxySwitch.  The DvsName field of the result is the name of the switch as seen by the host.
+// An error is returned if the host is not a member of the switch.
+func (h HostSystem) ProxySwitch(ctx context.Context, pg *DistributedVirtualPortgroup) (*types.HostProxySwitch, error) {
+	backing, err := pg.EthernetCardBackingInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	dvp, ok := backing.(*types.VirtualEthernetCardDistributedVirtualPortBackingInfo)
+	if !ok {
+		return nil, fmt.Errorf("%s has unexpected backing type %T", pg.Reference(), backing)
+	}
+
+	uuid := dvp.Port.SwitchUuid
+
+	var mh mo.HostSystem
+
+	err = h.Properties(ctx, h.Reference(), []string{"config.network.proxySwitch"}, &mh)
+	if err != nil {
+		return nil, err
+	}
+
+	if mh.Config != nil && mh.Config.Network != nil {
+		for i := range mh.Config.Network.ProxySwitch {
+			if s := &mh.Config.Network.ProxySwitch[i]; s.DvsUuid == uuid {
+				return s, nil
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("%s is not a member of the switch of %s", h.Reference(), pg.Reference())
+}
+
 func (h HostSystem) Disconnect(ctx context.Context) (*Task, error) {
 	req := types.DisconnectHost_Task{
 		This: h.Reference(),
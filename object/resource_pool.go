@@ -18,9 +18,11 @@ package object
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/vmware/govmomi/vim25"
 	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/mo"
 	"github.com/vmware/govmomi/vim25/types"
 )
 
@@ -34,7 +36,33 @@ func NewResourcePool(c *vim25.Client, ref types.ManagedObjectReference) *Resourc
 	}
 }
 
+// Owner returns the ClusterComputeResource or ComputeResource that owns the pool.
+func (p ResourcePool) Owner(ctx context.Context) (Reference, error) {
+	var mp mo.ResourcePool
+
+	err := p.Properties(ctx, p.Reference(), []string{"owner"}, &mp)
+	if err != nil {
+		return nil, err
+	}
+
+	switch mp.Owner.Type {
+	case "ClusterComputeResource":
+		return NewClusterComputeResource(p.c, mp.Owner), nil
+	case "ComputeResource":
+		return NewComputeResource(p.c, mp.Owner), nil
+	default:
+		return nil, fmt.Errorf("unknown resource pool owner type: %s", mp.Owner.Type)
+	}
+}
+
+// ImportVApp starts the import of a VM or vApp into the pool from the given spec, as created by
+// OvfManager.CreateImportSpec, returning the lease used to upload the disks.  The folder is required
+// with vCenter, and host may be nil for a standalone host or a cluster with DRS enabled.
 func (p ResourcePool) ImportVApp(ctx context.Context, spec types.BaseImportSpec, folder *Folder, host *HostSystem) (*HttpNfcLease, error) {
+	if spec == nil {
+		return nil, fmt.Errorf("import spec is required")
+	}
+
 	req := types.ImportVApp{
 		This: p.Reference(),
 		Spec: spec,
@@ -17,7 +17,13 @@ limitations under the License.
 package object
 
 import (
+	"context"
+	"errors"
+	"path"
+
+	"github.com/vmware/govmomi/property"
 	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
 	"github.com/vmware/govmomi/vim25/types"
 )
 
@@ -32,3 +38,144 @@ func NewStoragePod(c *vim25.Client, ref types.ManagedObjectReference) *StoragePo
 		},
 	}
 }
+
+// ChildDatastores returns the member datastores of the pod, with their InventoryPath set
+// relative to the pod's InventoryPath.
+func (p StoragePod) ChildDatastores(ctx context.Context) ([]*Datastore, error) {
+	var mp mo.StoragePod
+
+	err := p.Properties(ctx, p.Reference(), []string{"childEntity"}, &mp)
+	if err != nil {
+		return nil, err
+	}
+
+	var refs []types.ManagedObjectReference
+	for _, e := range mp.ChildEntity {
+		if e.Type == "Datastore" {
+			refs = append(refs, e)
+		}
+	}
+
+	if len(refs) == 0 {
+		return nil, nil
+	}
+
+	var mds []mo.Datastore
+	pc := property.DefaultCollector(p.c)
+	err = pc.Retrieve(ctx, refs, []string{"name"}, &mds)
+	if err != nil {
+		return nil, err
+	}
+
+	var dss []*Datastore
+	for _, md := range mds {
+		ds := NewDatastore(p.c, md.Reference())
+		ds.InventoryPath = path.Join(p.InventoryPath, md.Name)
+		dss = append(dss, ds)
+	}
+
+	return dss, nil
+}
+
+// DiskPlacement pairs a disk with the datastore recommended for it, as returned by RecommendDiskPlacement.
+type DiskPlacement struct {
+	Disk      *types.VirtualDisk
+	Datastore types.ManagedObjectReference
+}
+
+// RecommendDiskPlacement asks Storage DRS for a datastore in the pod for each disk created by the given
+// VM config spec, such as the spec of a VM to be created in pool.  The disks are placed together, such that
+// affinity rules of the pod apply, and the top recommendation is returned.  The spec is not modified.
+func (p StoragePod) RecommendDiskPlacement(ctx context.Context, spec *types.VirtualMachineConfigSpec, pool *ResourcePool) ([]DiskPlacement, error) {
+	ref := p.Reference()
+
+	config := types.VmPodConfigForPlacement{
+		StoragePod: ref,
+	}
+
+	var disks []*types.VirtualDisk
+
+	for _, change := range spec.DeviceChange {
+		s := change.GetVirtualDeviceConfigSpec()
+		if s.Operation != types.VirtualDeviceConfigSpecOperationAdd || s.FileOperation != types.VirtualDeviceConfigSpecFileOperationCreate {
+			continue
+		}
+
+		d, ok := s.Device.(*types.VirtualDisk)
+		if !ok {
+			continue
+		}
+
+		config.Disk = append(config.Disk, types.PodDiskLocator{
+			DiskId:          d.Key,
+			DiskBackingInfo: d.Backing,
+		})
+		disks = append(disks, d)
+	}
+
+	if len(disks) == 0 {
+		return nil, errors.New("no disks to place")
+	}
+
+	sps := types.StoragePlacementSpec{
+		Type: string(types.StoragePlacementSpecPlacementTypeCreate),
+		PodSelectionSpec: types.StorageDrsPodSelectionSpec{
+			StoragePod:      &ref,
+			InitialVmConfig: []types.VmPodConfigForPlacement{config},
+		},
+		ConfigSpec: spec,
+	}
+
+	if pool != nil {
+		sps.ResourcePool = types.NewReference(pool.Reference())
+	}
+
+	result, err := NewStorageResourceManager(p.c).RecommendDatastores(ctx, sps)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(result.Recommendations) == 0 {
+		return nil, errors.New("no storage placement recommendations")
+	}
+
+	return diskPlacements(disks, result.Recommendations[0])
+}
+
+// diskPlacements maps each disk to a datastore per the given recommendation.  Disks without a disk locator
+// in the recommendation are placed on the destination of its first StoragePlacementAction.
+func diskPlacements(disks []*types.VirtualDisk, rec types.ClusterRecommendation) ([]DiskPlacement, error) {
+	var dest *types.ManagedObjectReference
+	located := make(map[int32]types.ManagedObjectReference)
+
+	for _, a := range rec.Action {
+		action, ok := a.(*types.StoragePlacementAction)
+		if !ok {
+			continue
+		}
+
+		if dest == nil {
+			dest = &action.Destination
+		}
+
+		for _, disk := range action.RelocateSpec.Disk {
+			located[disk.DiskId] = disk.Datastore
+		}
+	}
+
+	placements := make([]DiskPlacement, len(disks))
+
+	for i, disk := range disks {
+		ds, ok := located[disk.Key]
+		if !ok {
+			if dest == nil {
+				return nil, errors.New("recommendation does not include a storage placement")
+			}
+			ds = *dest
+		}
+
+		placements[i] = DiskPlacement{disk, ds}
+	}
+
+	return placements, nil
+}
@@ -0,0 +1,75 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package object
+
+import (
+	"testing"
+
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func TestDiskPlacements(t *testing.T) {
+	ds1 := types.ManagedObjectReference{Type: "Datastore", Value: "ds-1"}
+	ds2 := types.ManagedObjectReference{Type: "Datastore", Value: "ds-2"}
+
+	disks := []*types.VirtualDisk{
+		{VirtualDevice: types.VirtualDevice{Key: -100}},
+		{VirtualDevice: types.VirtualDevice{Key: -101}},
+		{VirtualDevice: types.VirtualDevice{Key: -102}},
+	}
+
+	rec := types.ClusterRecommendation{
+		Action: []types.BaseClusterAction{
+			&types.StoragePlacementAction{
+				Destination: ds1,
+				RelocateSpec: types.VirtualMachineRelocateSpec{
+					Disk: []types.VirtualMachineRelocateSpecDiskLocator{
+						{DiskId: -101, Datastore: ds2},
+					},
+				},
+			},
+			&types.StoragePlacementAction{
+				Destination: ds2,
+				RelocateSpec: types.VirtualMachineRelocateSpec{
+					Disk: []types.VirtualMachineRelocateSpecDiskLocator{
+						{DiskId: -102, Datastore: ds2},
+					},
+				},
+			},
+		},
+	}
+
+	placements, err := diskPlacements(disks, rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := []types.ManagedObjectReference{ds1, ds2, ds2}
+
+	for i, p := range placements {
+		if p.Disk != disks[i] {
+			t.Errorf("%d: unexpected disk", i)
+		}
+		if p.Datastore != expect[i] {
+			t.Errorf("%d: expected %s, got %s", i, expect[i], p.Datastore)
+		}
+	}
+
+	if _, err = diskPlacements(disks, types.ClusterRecommendation{}); err == nil {
+		t.Error("expected error")
+	}
+}
@@ -51,3 +51,9 @@ func (t *Task) WaitForResult(ctx context.Context, s progress.Sinker) (*types.Tas
 	p := property.DefaultCollector(t.c)
 	return task.Wait(ctx, t.Reference(), p, s)
 }
+
+// WaitForInfo is like WaitForResult, but calls fn with each update of the task's info property.
+func (t *Task) WaitForInfo(ctx context.Context, fn func(types.TaskInfo)) (*types.TaskInfo, error) {
+	p := property.DefaultCollector(t.c)
+	return task.WaitForInfo(ctx, t.Reference(), p, fn)
+}
@@ -138,6 +138,10 @@ func (v VirtualMachine) Destroy(ctx context.Context) (*Task, error) {
 	return NewTask(v.c, res.Returnval), nil
 }
 
+// Clone creates a clone of this VirtualMachine in the given folder.
+// The placement of the clone is specified by the Location field of config, typically populated using
+// the ResourcePool, Datastore and HostSystem objects returned by the Finder.
+// When the task completes, the TaskInfo.Result field contains the ManagedObjectReference of the new VM.
 func (v VirtualMachine) Clone(ctx context.Context, folder *Folder, name string, config types.VirtualMachineCloneSpec) (*Task, error) {
 	req := types.CloneVM_Task{
 		This:   v.Reference(),
@@ -183,7 +187,13 @@ func (v VirtualMachine) Relocate(ctx context.Context, config types.VirtualMachin
 	return NewTask(v.c, res.Returnval), nil
 }
 
+// Reconfigure submits the given spec to change the configuration of the VM.
+// Obvious errors in the spec, as checked by validateConfigSpec, are returned before a request is made.
 func (v VirtualMachine) Reconfigure(ctx context.Context, config types.VirtualMachineConfigSpec) (*Task, error) {
+	if err := validateConfigSpec(config); err != nil {
+		return nil, err
+	}
+
 	req := types.ReconfigVM_Task{
 		This: v.Reference(),
 		Spec: config,
@@ -197,6 +207,46 @@ func (v VirtualMachine) Reconfigure(ctx context.Context, config types.VirtualMac
 	return NewTask(v.c, res.Returnval), nil
 }
 
+// validateConfigSpec checks the spec for values the server would reject regardless of the VM's current configuration.
+// Fields left unset are not checked.
+func validateConfigSpec(spec types.VirtualMachineConfigSpec) error {
+	if spec.NumCPUs < 0 {
+		return fmt.Errorf("invalid numCPUs: %d", spec.NumCPUs)
+	}
+
+	if spec.NumCoresPerSocket < 0 {
+		return fmt.Errorf("invalid numCoresPerSocket: %d", spec.NumCoresPerSocket)
+	}
+
+	if spec.NumCPUs > 0 && spec.NumCoresPerSocket > 0 && spec.NumCPUs%spec.NumCoresPerSocket != 0 {
+		return fmt.Errorf("numCPUs (%d) must be a multiple of numCoresPerSocket (%d)", spec.NumCPUs, spec.NumCoresPerSocket)
+	}
+
+	if spec.MemoryMB < 0 || spec.MemoryMB%4 != 0 {
+		return fmt.Errorf("invalid memoryMB: %d (must be a multiple of 4)", spec.MemoryMB)
+	}
+
+	if spec.MemoryAllocation != nil {
+		a := spec.MemoryAllocation.GetResourceAllocationInfo()
+
+		if spec.MemoryMB > 0 && a.Reservation > spec.MemoryMB {
+			return fmt.Errorf("memory reservation (%d) exceeds memoryMB (%d)", a.Reservation, spec.MemoryMB)
+		}
+
+		if a.Limit > 0 && a.Reservation > a.Limit {
+			return fmt.Errorf("memory reservation (%d) exceeds limit (%d)", a.Reservation, a.Limit)
+		}
+	}
+
+	for i, change := range spec.DeviceChange {
+		if change == nil || change.GetVirtualDeviceConfigSpec().Device == nil {
+			return fmt.Errorf("deviceChange[%d] has no device", i)
+		}
+	}
+
+	return nil
+}
+
 func (v VirtualMachine) WaitForIP(ctx context.Context) (string, error) {
 	var ip string
 
@@ -298,6 +348,25 @@ func (v VirtualMachine) WaitForNetIP(ctx context.Context, v4 bool) (map[string][
 	return macs, nil
 }
 
+// Networks returns the networks the VirtualMachine is attached to, from its network property.
+func (v VirtualMachine) Networks(ctx context.Context) ([]NetworkReference, error) {
+	var o mo.VirtualMachine
+
+	err := v.Properties(ctx, v.Reference(), []string{"network"}, &o)
+	if err != nil {
+		return nil, err
+	}
+
+	var ns []NetworkReference
+	for _, ref := range o.Network {
+		if n, ok := NewReference(v.c, ref).(NetworkReference); ok {
+			ns = append(ns, n)
+		}
+	}
+
+	return ns, nil
+}
+
 // Device returns the VirtualMachine's config.hardware.device property.
 func (v VirtualMachine) Device(ctx context.Context) (VirtualDeviceList, error) {
 	var o mo.VirtualMachine
@@ -353,6 +422,15 @@ func (v VirtualMachine) ResourcePool(ctx context.Context) (*ResourcePool, error)
 }
 
 func (v VirtualMachine) configureDevice(ctx context.Context, op types.VirtualDeviceConfigSpecOperation, fop types.VirtualDeviceConfigSpecFileOperation, devices ...types.BaseVirtualDevice) error {
+	task, err := v.configureDeviceTask(ctx, op, fop, devices...)
+	if err != nil {
+		return err
+	}
+
+	return task.Wait(ctx)
+}
+
+func (v VirtualMachine) configureDeviceTask(ctx context.Context, op types.VirtualDeviceConfigSpecOperation, fop types.VirtualDeviceConfigSpecFileOperation, devices ...types.BaseVirtualDevice) (*Task, error) {
 	spec := types.VirtualMachineConfigSpec{}
 
 	for _, device := range devices {
@@ -380,12 +458,7 @@ func (v VirtualMachine) configureDevice(ctx context.Context, op types.VirtualDev
 		spec.DeviceChange = append(spec.DeviceChange, config)
 	}
 
-	task, err := v.Reconfigure(ctx, spec)
-	if err != nil {
-		return err
-	}
-
-	return task.Wait(ctx)
+	return v.Reconfigure(ctx, spec)
 }
 
 // AddDevice adds the given devices to the VirtualMachine
@@ -407,6 +480,20 @@ func (v VirtualMachine) RemoveDevice(ctx context.Context, keepFiles bool, device
 	return v.configureDevice(ctx, types.VirtualDeviceConfigSpecOperationRemove, fop, device...)
 }
 
+// AddDeviceTask is like AddDevice, but returns the reconfigure Task rather than waiting for it to complete.
+func (v VirtualMachine) AddDeviceTask(ctx context.Context, device ...types.BaseVirtualDevice) (*Task, error) {
+	return v.configureDeviceTask(ctx, types.VirtualDeviceConfigSpecOperationAdd, types.VirtualDeviceConfigSpecFileOperationCreate, device...)
+}
+
+// RemoveDeviceTask is like RemoveDevice, but returns the reconfigure Task rather than waiting for it to complete.
+func (v VirtualMachine) RemoveDeviceTask(ctx context.Context, keepFiles bool, device ...types.BaseVirtualDevice) (*Task, error) {
+	fop := types.VirtualDeviceConfigSpecFileOperationDestroy
+	if keepFiles {
+		fop = ""
+	}
+	return v.configureDeviceTask(ctx, types.VirtualDeviceConfigSpecOperationRemove, fop, device...)
+}
+
 // BootOptions returns the VirtualMachine's config.bootOptions property.
 func (v VirtualMachine) BootOptions(ctx context.Context) (*types.VirtualMachineBootOptions, error) {
 	var o mo.VirtualMachine
@@ -503,11 +590,11 @@ func (m snapshotMap) add(parent string, tree []types.VirtualMachineSnapshotTree)
 	}
 }
 
-// findSnapshot supports snapshot lookup by name, where name can be:
+// FindSnapshot supports snapshot lookup by name, where name can be:
 // 1) snapshot ManagedObjectReference.Value (unique)
 // 2) snapshot name (may not be unique)
 // 3) snapshot tree path (may not be unique)
-func (v VirtualMachine) findSnapshot(ctx context.Context, name string) (Reference, error) {
+func (v VirtualMachine) FindSnapshot(ctx context.Context, name string) (Reference, error) {
 	var o mo.VirtualMachine
 
 	err := v.Properties(ctx, v.Reference(), []string{"snapshot"}, &o)
@@ -535,7 +622,7 @@ func (v VirtualMachine) findSnapshot(ctx context.Context, name string) (Referenc
 
 // RemoveSnapshot removes a named snapshot
 func (v VirtualMachine) RemoveSnapshot(ctx context.Context, name string, removeChildren bool, consolidate *bool) (*Task, error) {
-	snapshot, err := v.findSnapshot(ctx, name)
+	snapshot, err := v.FindSnapshot(ctx, name)
 	if err != nil {
 		return nil, err
 	}
@@ -571,7 +658,7 @@ func (v VirtualMachine) RevertToCurrentSnapshot(ctx context.Context, suppressPow
 
 // RevertToSnapshot reverts to a named snapshot
 func (v VirtualMachine) RevertToSnapshot(ctx context.Context, name string, suppressPowerOn bool) (*Task, error) {
-	snapshot, err := v.findSnapshot(ctx, name)
+	snapshot, err := v.FindSnapshot(ctx, name)
 	if err != nil {
 		return nil, err
 	}
@@ -656,7 +743,14 @@ func (v VirtualMachine) MarkAsVirtualMachine(ctx context.Context, pool ResourceP
 	return nil
 }
 
+// Migrate moves the VM to the given resource pool and/or host, such as those resolved by find.Finder,
+// using vMotion if the VM is powered on.  At least one of pool or host must be given; if pool is nil,
+// the VM stays in its current pool.  If state is set, the migration fails unless the VM is in that power state.
 func (v VirtualMachine) Migrate(ctx context.Context, pool *ResourcePool, host *HostSystem, priority types.VirtualMachineMovePriority, state types.VirtualMachinePowerState) (*Task, error) {
+	if pool == nil && host == nil {
+		return nil, errors.New("migrate requires a resource pool or host")
+	}
+
 	req := types.MigrateVM_Task{
 		This:     v.Reference(),
 		Priority: priority,
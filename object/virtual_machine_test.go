@@ -204,3 +204,39 @@ func TestVirtualMachineSnapshotMap(t *testing.T) {
 		}
 	}
 }
+
+func TestValidateConfigSpec(t *testing.T) {
+	tests := []struct {
+		spec  types.VirtualMachineConfigSpec
+		valid bool
+	}{
+		{types.VirtualMachineConfigSpec{}, true},
+		{types.VirtualMachineConfigSpec{NumCPUs: 4, NumCoresPerSocket: 2, MemoryMB: 2048}, true},
+		{types.VirtualMachineConfigSpec{NumCPUs: -1}, false},
+		{types.VirtualMachineConfigSpec{NumCPUs: 3, NumCoresPerSocket: 2}, false},
+		{types.VirtualMachineConfigSpec{MemoryMB: 1023}, false},
+		{types.VirtualMachineConfigSpec{
+			MemoryMB:         1024,
+			MemoryAllocation: &types.ResourceAllocationInfo{Reservation: 2048},
+		}, false},
+		{types.VirtualMachineConfigSpec{
+			MemoryAllocation: &types.ResourceAllocationInfo{Reservation: 512, Limit: 256},
+		}, false},
+		{types.VirtualMachineConfigSpec{
+			MemoryAllocation: &types.ResourceAllocationInfo{Reservation: 512, Limit: -1},
+		}, true},
+		{types.VirtualMachineConfigSpec{
+			DeviceChange: []types.BaseVirtualDeviceConfigSpec{&types.VirtualDeviceConfigSpec{}},
+		}, false},
+	}
+
+	for i, test := range tests {
+		err := validateConfigSpec(test.spec)
+		if test.valid && err != nil {
+			t.Errorf("%d: unexpected error: %s", i, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("%d: expected error", i)
+		}
+	}
+}
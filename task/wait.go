@@ -125,3 +125,46 @@ func Wait(ctx context.Context, ref types.ManagedObjectReference, pc *property.Co
 
 	return cb.info, cb.err
 }
+
+// WaitForInfo is like Wait, but calls fn with each update of the task's "info"
+// property, including the final update. This allows the caller to render
+// progress using any of the TaskInfo fields, such as Progress and
+// DescriptionId, rather than only the completion percentage.
+func WaitForInfo(ctx context.Context, ref types.ManagedObjectReference, pc *property.Collector, fn func(types.TaskInfo)) (*types.TaskInfo, error) {
+	var info *types.TaskInfo
+
+	err := property.Wait(ctx, pc, ref, []string{"info"}, func(pc []types.PropertyChange) bool {
+		for _, c := range pc {
+			if c.Name != "info" || c.Op != types.PropertyChangeOpAssign || c.Val == nil {
+				continue
+			}
+
+			ti := c.Val.(types.TaskInfo)
+			info = &ti
+		}
+
+		if info == nil {
+			return false
+		}
+
+		if fn != nil {
+			fn(*info)
+		}
+
+		switch info.State {
+		case types.TaskInfoStateSuccess, types.TaskInfoStateError:
+			return true
+		default:
+			return false
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if info.Error != nil {
+		return info, Error{info.Error}
+	}
+
+	return info, nil
+}
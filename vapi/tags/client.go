@@ -0,0 +1,348 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package tags is a client for the vSphere tagging service, which is only available via the vCenter REST API.
+package tags
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+const (
+	sessionPath        = "/com/vmware/cis/session"
+	categoryPath       = "/com/vmware/cis/tagging/category"
+	tagPath            = "/com/vmware/cis/tagging/tag"
+	associationPath    = "/com/vmware/cis/tagging/tag-association"
+	sessionHeader      = "vmware-api-session-id"
+	defaultRESTPath    = "/rest"
+	actionListTags     = "list-tags-for-category"
+	actionListAttached = "list-attached-objects"
+)
+
+// Client is a vCenter REST API client for the tagging service.  It uses the transport of the given vim25 client,
+// including its TLS configuration, but has its own session, which must be created with Login.
+type Client struct {
+	c *soap.Client
+	u *url.URL
+
+	session string
+
+	mu  sync.Mutex
+	ids map[string]map[string]string
+}
+
+// Category is a tag category.
+type Category struct {
+	ID              string   `json:"id"`
+	Name            string   `json:"name"`
+	Description     string   `json:"description"`
+	Cardinality     string   `json:"cardinality"`
+	AssociableTypes []string `json:"associable_types"`
+}
+
+// Tag is a tag within a Category.
+type Tag struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	CategoryID  string `json:"category_id"`
+}
+
+// NewClient returns a tagging client for the vCenter of the given client.
+func NewClient(c *vim25.Client) *Client {
+	u := c.URL()
+	u.Path = defaultRESTPath
+	u.RawQuery = ""
+	u.User = nil
+
+	return &Client{c: c.Client, u: u}
+}
+
+// Login creates a REST API session with the given credentials.
+func (c *Client) Login(ctx context.Context, user *url.Userinfo) error {
+	req, err := c.newRequest(http.MethodPost, sessionPath, nil)
+	if err != nil {
+		return err
+	}
+
+	if user != nil {
+		password, _ := user.Password()
+		req.SetBasicAuth(user.Username(), password)
+	}
+
+	var id string
+	if err = c.do(ctx, req, &id); err != nil {
+		return err
+	}
+
+	c.session = id
+
+	return nil
+}
+
+// Logout deletes the REST API session.
+func (c *Client) Logout(ctx context.Context) error {
+	req, err := c.newRequest(http.MethodDelete, sessionPath, nil)
+	if err != nil {
+		return err
+	}
+
+	err = c.do(ctx, req, nil)
+	c.session = ""
+
+	return err
+}
+
+// ListCategories returns the IDs of all tag categories.
+func (c *Client) ListCategories(ctx context.Context) ([]string, error) {
+	var ids []string
+	return ids, c.call(ctx, http.MethodGet, categoryPath, "", &ids)
+}
+
+// GetCategory returns the category with the given ID.
+func (c *Client) GetCategory(ctx context.Context, id string) (*Category, error) {
+	var category Category
+	return &category, c.call(ctx, http.MethodGet, categoryPath+"/id:"+id, "", &category)
+}
+
+// ListTagsForCategory returns the IDs of the tags within the category with the given ID.
+func (c *Client) ListTagsForCategory(ctx context.Context, id string) ([]string, error) {
+	var ids []string
+	return ids, c.call(ctx, http.MethodPost, tagPath+"/id:"+id, actionListTags, &ids)
+}
+
+// GetTag returns the tag with the given ID.
+func (c *Client) GetTag(ctx context.Context, id string) (*Tag, error) {
+	var tag Tag
+	return &tag, c.call(ctx, http.MethodGet, tagPath+"/id:"+id, "", &tag)
+}
+
+// ListAttachedObjects returns the objects the tag with the given ID is attached to.
+func (c *Client) ListAttachedObjects(ctx context.Context, id string) ([]types.ManagedObjectReference, error) {
+	var objs []struct {
+		ID   string `json:"id"`
+		Type string `json:"type"`
+	}
+
+	err := c.call(ctx, http.MethodPost, associationPath+"/id:"+id, actionListAttached, &objs)
+	if err != nil {
+		return nil, err
+	}
+
+	refs := make([]types.ManagedObjectReference, len(objs))
+	for i, obj := range objs {
+		refs[i] = types.ManagedObjectReference{Type: obj.Type, Value: obj.ID}
+	}
+
+	return refs, nil
+}
+
+// AttachedObjects returns the objects attached to the tag with the given name or ID, within the category
+// with the given name or ID.  This implements the find.TagAssociations interface.
+func (c *Client) AttachedObjects(ctx context.Context, category string, tag string) ([]types.ManagedObjectReference, error) {
+	cid, err := c.categoryID(ctx, category)
+	if err != nil {
+		return nil, err
+	}
+
+	tid, err := c.tagID(ctx, category, cid, tag)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.ListAttachedObjects(ctx, tid)
+}
+
+// isName returns true if id is a name rather than an ID, which is a URN such as "urn:vmomi:InventoryServiceTag:...".
+func isName(id string) bool {
+	return !strings.HasPrefix(id, "urn:")
+}
+
+// categoryID returns the ID of the category with the given name or ID.
+func (c *Client) categoryID(ctx context.Context, name string) (string, error) {
+	if !isName(name) {
+		return name, nil
+	}
+
+	id, ok, err := c.lookup(categoryPath, name, func() (map[string]string, error) {
+		ids, err := c.ListCategories(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		names := make(map[string]string, len(ids))
+		for _, id := range ids {
+			cat, err := c.GetCategory(ctx, id)
+			if err != nil {
+				return nil, err
+			}
+			names[cat.Name] = cat.ID
+		}
+
+		return names, nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if !ok {
+		return "", fmt.Errorf("category %q not found", name)
+	}
+
+	return id, nil
+}
+
+// tagID returns the ID of the tag with the given name or ID within the category with the given ID.
+func (c *Client) tagID(ctx context.Context, category string, cid string, name string) (string, error) {
+	if !isName(name) {
+		t, err := c.GetTag(ctx, name)
+		if err != nil {
+			return "", err
+		}
+
+		if t.CategoryID != cid {
+			return "", fmt.Errorf("tag %q not found in category %q", name, category)
+		}
+
+		return t.ID, nil
+	}
+
+	id, ok, err := c.lookup(cid, name, func() (map[string]string, error) {
+		ids, err := c.ListTagsForCategory(ctx, cid)
+		if err != nil {
+			return nil, err
+		}
+
+		names := make(map[string]string, len(ids))
+		for _, id := range ids {
+			t, err := c.GetTag(ctx, id)
+			if err != nil {
+				return nil, err
+			}
+			names[t.Name] = t.ID
+		}
+
+		return names, nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if !ok {
+		return "", fmt.Errorf("tag %q not found in category %q", name, category)
+	}
+
+	return id, nil
+}
+
+// lookup returns the ID of name within the given scope, either categoryPath or the ID of a category.
+// The IDs of all names within the scope are cached by the Client, as the REST API can only list categories
+// and tags by ID, and are only loaded again when name is not found, such as when it was created since.
+func (c *Client) lookup(scope string, name string, load func() (map[string]string, error)) (string, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if id, ok := c.ids[scope][name]; ok {
+		return id, true, nil
+	}
+
+	names, err := load()
+	if err != nil {
+		return "", false, err
+	}
+
+	if c.ids == nil {
+		c.ids = make(map[string]map[string]string)
+	}
+	c.ids[scope] = names
+
+	id, ok := names[name]
+	return id, ok, nil
+}
+
+func (c *Client) newRequest(method string, path string, body io.Reader) (*http.Request, error) {
+	u := *c.u
+	u.Path += path
+
+	req, err := http.NewRequest(method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Accept", "application/json")
+	if c.session != "" {
+		req.Header.Set(sessionHeader, c.session)
+	}
+
+	return req, nil
+}
+
+// call sends a request with the given action, if any, and decodes the "value" field of the response into res.
+func (c *Client) call(ctx context.Context, method string, path string, action string, res interface{}) error {
+	var body io.Reader
+	if method == http.MethodPost {
+		body = bytes.NewReader([]byte("{}"))
+	}
+
+	req, err := c.newRequest(method, path, body)
+	if err != nil {
+		return err
+	}
+
+	if action != "" {
+		req.URL.RawQuery = "~action=" + action
+	}
+
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	return c.do(ctx, req, res)
+}
+
+func (c *Client) do(ctx context.Context, req *http.Request, res interface{}) error {
+	r, err := c.c.Client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer r.Body.Close()
+
+	if r.StatusCode < 200 || r.StatusCode > 299 {
+		return fmt.Errorf("%s %s: %s", req.Method, req.URL.Path, r.Status)
+	}
+
+	if res == nil {
+		return nil
+	}
+
+	value := struct {
+		Value interface{} `json:"value"`
+	}{res}
+
+	return json.NewDecoder(r.Body).Decode(&value)
+}
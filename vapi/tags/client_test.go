@@ -0,0 +1,180 @@
+/*
+Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tags
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+const (
+	envID  = "urn:vmomi:InventoryServiceCategory:env:GLOBAL"
+	teamID = "urn:vmomi:InventoryServiceCategory:team:GLOBAL"
+	webID  = "urn:vmomi:InventoryServiceTag:web:GLOBAL"
+	dbID   = "urn:vmomi:InventoryServiceTag:db:GLOBAL"
+)
+
+// tagging is an http.Handler implementing the parts of the tagging REST API used by Client.
+func tagging(t *testing.T, requests *int) http.Handler {
+	reply := func(w http.ResponseWriter, value interface{}) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"value": value})
+	}
+
+	routes := map[string]func(*http.Request) interface{}{
+		"GET /rest" + categoryPath: func(*http.Request) interface{} {
+			return []string{envID, teamID}
+		},
+		"GET /rest" + categoryPath + "/id:" + envID: func(*http.Request) interface{} {
+			return Category{ID: envID, Name: "env"}
+		},
+		"GET /rest" + categoryPath + "/id:" + teamID: func(*http.Request) interface{} {
+			return Category{ID: teamID, Name: "team"}
+		},
+		"POST /rest" + tagPath + "/id:" + teamID + " list-tags-for-category": func(*http.Request) interface{} {
+			return []string{webID, dbID}
+		},
+		"GET /rest" + tagPath + "/id:" + webID: func(*http.Request) interface{} {
+			return Tag{ID: webID, Name: "web", CategoryID: teamID}
+		},
+		"GET /rest" + tagPath + "/id:" + dbID: func(*http.Request) interface{} {
+			return Tag{ID: dbID, Name: "db", CategoryID: teamID}
+		},
+		"POST /rest" + associationPath + "/id:" + dbID + " list-attached-objects": func(*http.Request) interface{} {
+			return []map[string]string{{"id": "vm-42", "type": "VirtualMachine"}, {"id": "host-7", "type": "HostSystem"}}
+		},
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/rest/com/vmware/cis/session" {
+			switch r.Method {
+			case http.MethodPost:
+				if user, pass, ok := r.BasicAuth(); !ok || user != "user" || pass != "pass" {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				reply(w, "session-1")
+			case http.MethodDelete:
+				w.WriteHeader(http.StatusOK)
+			}
+			return
+		}
+
+		*requests++
+
+		if r.Header.Get("vmware-api-session-id") != "session-1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		key := r.Method + " " + r.URL.Path
+		if action := r.URL.Query().Get("~action"); action != "" {
+			key += " " + action
+		}
+
+		route, ok := routes[key]
+		if !ok {
+			t.Logf("not found: %s", key)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		reply(w, route(r))
+	})
+}
+
+func TestAttachedObjects(t *testing.T) {
+	var requests int
+	s := httptest.NewServer(tagging(t, &requests))
+	defer s.Close()
+
+	u, err := url.Parse(s.URL + "/sdk")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	c := NewClient(&vim25.Client{Client: soap.NewClient(u, true)})
+
+	if _, err = c.AttachedObjects(ctx, "team", "db"); err == nil {
+		t.Fatal("expected error without a session")
+	}
+
+	if err = c.Login(ctx, url.UserPassword("user", "enoent")); err == nil {
+		t.Fatal("expected error with invalid credentials")
+	}
+
+	if err = c.Login(ctx, url.UserPassword("user", "pass")); err != nil {
+		t.Fatal(err)
+	}
+
+	expect := []types.ManagedObjectReference{
+		{Type: "VirtualMachine", Value: "vm-42"},
+		{Type: "HostSystem", Value: "host-7"},
+	}
+
+	// Names are resolved to IDs on first use only, IDs are used as is with the tag checked to be in the category
+	tests := []struct {
+		category, tag string
+		requests      int
+	}{
+		{"team", "db", 7},
+		{"team", "db", 1},
+		{"team", dbID, 2},
+		{teamID, dbID, 2},
+	}
+
+	for _, test := range tests {
+		requests = 0
+
+		refs, err := c.AttachedObjects(ctx, test.category, test.tag)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(refs) != len(expect) || refs[0] != expect[0] || refs[1] != expect[1] {
+			t.Errorf("%s/%s: unexpected objects: %v", test.category, test.tag, refs)
+		}
+
+		if requests != test.requests {
+			t.Errorf("%s/%s: expected %d requests, got %d", test.category, test.tag, test.requests, requests)
+		}
+	}
+
+	if _, err = c.AttachedObjects(ctx, envID, dbID); err == nil {
+		t.Error("expected error for tag in another category")
+	}
+
+	if _, err = c.AttachedObjects(ctx, "team", "enoent"); err == nil {
+		t.Error("expected error for unknown tag")
+	}
+
+	if _, err = c.AttachedObjects(ctx, "enoent", "db"); err == nil {
+		t.Error("expected error for unknown category")
+	}
+
+	if err = c.Logout(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
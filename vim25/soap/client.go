@@ -454,7 +454,7 @@ func (c *Client) RoundTrip(ctx context.Context, reqBody, resBody HasFault) error
 	case http.StatusInternalServerError:
 		// Error, but typically includes a body explaining the error
 	default:
-		return errors.New(res.Status)
+		return wrapStatusError(res)
 	}
 
 	dec := xml.NewDecoder(res.Body)
@@ -18,6 +18,7 @@ package soap
 
 import (
 	"fmt"
+	"net/http"
 	"reflect"
 
 	"github.com/vmware/govmomi/vim25/types"
@@ -56,6 +57,15 @@ func (v vimFaultError) Fault() types.BaseMethodFault {
 	return v.fault
 }
 
+type statusError struct {
+	code   int
+	status string
+}
+
+func (s statusError) Error() string {
+	return s.status
+}
+
 func Wrap(err error) error {
 	switch err.(type) {
 	case regularError:
@@ -64,6 +74,8 @@ func Wrap(err error) error {
 		return err
 	case vimFaultError:
 		return err
+	case statusError:
+		return err
 	}
 
 	return WrapRegularError(err)
@@ -107,3 +119,18 @@ func IsVimFault(err error) bool {
 func ToVimFault(err error) types.BaseMethodFault {
 	return err.(vimFaultError).fault
 }
+
+func wrapStatusError(res *http.Response) error {
+	return statusError{res.StatusCode, res.Status}
+}
+
+// IsStatusError returns true if the error is an unexpected HTTP response status returned by Client.RoundTrip.
+func IsStatusError(err error) bool {
+	_, ok := err.(statusError)
+	return ok
+}
+
+// ToStatusCode returns the HTTP response status code of an error for which IsStatusError returns true.
+func ToStatusCode(err error) int {
+	return err.(statusError).code
+}